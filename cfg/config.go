@@ -1,6 +1,9 @@
 package cfg
 
-import "go-dao-pattern/pkg/storage/mysql"
+import (
+	"go-dao-pattern/pkg/storage/mysql"
+	"time"
+)
 
 var (
 	MysqlConfig = mysql.ConnectionOptions{
@@ -13,6 +16,9 @@ var (
 		ConnMaxOpen:     5,
 		ConnMaxIdle:     2,
 		ConnMaxLifetime: 200,
+		ConnectTimeout:  5 * time.Second,
+		PingRetries:     3,
+		PingBackoff:     200 * time.Millisecond,
 	}
 )
 
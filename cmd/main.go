@@ -20,7 +20,11 @@ func FindUserDataBase() {
 		Db: cfg.MysqlConfig,
 	}
 
-	users.InitDataAccess(users.MySql, config)
+	ctx := context.NewBackgroundContext()
+	if err := users.InitDataAccess(ctx, users.MySql, config); err != nil {
+		println("BOOM!!!", err.Error())
+		return
+	}
 
 	f := users.Filters{
 		Fields: []db.Column{"age", "name"},
@@ -30,7 +34,6 @@ func FindUserDataBase() {
 		},
 	}
 
-	ctx := context.NewBackgroundContext()
 	data, err := users.Search(ctx, f)
 
 	if err != nil {
@@ -42,8 +45,8 @@ func FindUserDataBase() {
 }
 
 func FindUserMemory() {
-	users.InitDataAccess(users.Memory, nil)
 	ctx := context.NewBackgroundContext()
+	_ = users.InitDataAccess(ctx, users.Memory, nil)
 
 	// Given User
 	user := domain.User{
@@ -52,7 +55,7 @@ func FindUserMemory() {
 		Name: "Leonardo",
 	}
 
-	err := users.Create(ctx, user)
+	err := users.Create(ctx, &user)
 
 	//Search User
 	f := users.Filters{
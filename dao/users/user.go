@@ -24,8 +24,9 @@ type (
 	UserPages domain.UserPages
 
 	KeyOperator struct {
-		key   db.Column
-		Op    db.Operator
+		key db.Column
+		Op  db.Operator
+		// Value is a slice for In, NotIn and Between, and is ignored by IsNull and IsNotNull.
 		Value interface{}
 	}
 
@@ -34,13 +35,20 @@ type (
 		Id     KeyOperator
 		Name   KeyOperator
 		Age    KeyOperator
+		// Sort orders the users by id, name or age. Ties, and searches without Sort, are ordered by id.
+		Sort   []db.Order
 		Offset int
 		Limit  int
 	}
 
 	DataAccess interface {
 		Search(*context.Context, Filters) (domain.UserPages, error)
-		Create(*context.Context, domain.User) error
+		Create(*context.Context, *domain.User) error
+		Get(*context.Context, int) (domain.User, error)
+		Update(*context.Context, domain.User) error
+		Delete(*context.Context, int) error
+		Exists(*context.Context, int) (bool, error)
+		CountByAge(*context.Context, int, int) (domain.AgeBuckets, error)
 	}
 )
 
@@ -48,17 +56,46 @@ func Search(ctx *context.Context, f Filters) (domain.UserPages, error) {
 	return c.Search(ctx, f)
 }
 
-func Create(ctx *context.Context, u domain.User) error {
+func Create(ctx *context.Context, u *domain.User) error {
 	return c.Create(ctx, u)
 }
 
-func InitDataAccess(st StorageType, cfg *storage.Config) {
+func Get(ctx *context.Context, id int) (domain.User, error) {
+	return c.Get(ctx, id)
+}
+
+func Update(ctx *context.Context, u domain.User) error {
+	return c.Update(ctx, u)
+}
+
+func Delete(ctx *context.Context, id int) error {
+	return c.Delete(ctx, id)
+}
+
+func Exists(ctx *context.Context, id int) (bool, error) {
+	return c.Exists(ctx, id)
+}
+
+// CountByAge counts the users in age buckets of the given width, starting at multiples of it.
+// Buckets holding fewer than atLeast users are left out.
+func CountByAge(ctx *context.Context, width, atLeast int) (domain.AgeBuckets, error) {
+	return c.CountByAge(ctx, width, atLeast)
+}
+
+// InitDataAccess selects the backend used by the package functions. When the backend cannot be
+// reached the error is returned and the previous backend, if any, is kept.
+func InitDataAccess(ctx *context.Context, st StorageType, cfg *storage.Config) error {
 	switch st {
 	case MySql:
-		c = NewUserStorage(cfg.Db)
+		us, err := NewUserStorage(ctx, cfg.Db)
+		if err != nil {
+			return err
+		}
+		c = us
 	case Memory:
 		c = NewUserMemoryStorage()
 	default:
 		c = nil
 	}
+	return nil
 }
@@ -1,11 +1,16 @@
 package users
 
 import (
+	stdcontext "context"
 	"database/sql"
+	"fmt"
 	"go-dao-pattern/domain"
 	"go-dao-pattern/pkg/context"
+	oops "go-dao-pattern/pkg/errors"
 	"go-dao-pattern/pkg/storage/mysql"
 	"go-dao-pattern/pkg/storage/mysql/db"
+	"reflect"
+	"strings"
 )
 
 // Ensure type implements interface.
@@ -23,35 +28,184 @@ type userStorage struct {
 	storage *mysql.StorageClient
 }
 
-func NewUserStorage(options mysql.ConnectionOptions) *userStorage {
-	return &userStorage{
-		storage: mysql.InitConnection(options),
+func NewUserStorage(ctx *context.Context, options mysql.ConnectionOptions) (*userStorage, error) {
+	storage, err := mysql.InitConnection(ctx.Context(), options)
+	if err != nil {
+		return nil, err
 	}
+
+	return &userStorage{
+		storage: storage,
+	}, nil
 }
 
-func (us *userStorage) beginTx(ctx *context.Context) (*sql.Tx, error) {
+func (us *userStorage) BeginTx(ctx *context.Context) (*sql.Tx, error) {
 	return us.storage.BeginTx(ctx.Context(), nil)
 }
 
-func (us *userStorage) Create(ctx *context.Context, u domain.User) error {
-	panic("implement me")
+func (us *userStorage) Create(ctx *context.Context, u *domain.User) error {
+	cols, values := (*User)(u).insertion()
+	query, args, err := db.Insert(users).Columns(cols...).Values(values...).Build()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ExecStatement(ctx.Context(), us.storage, db.INSERT, string(users), query, args...)
+	return created(u, result, err)
 }
 
-func (us *userStorage) CreateTx(ctx *context.Context, tx *sql.Tx, u User) error {
-	panic("implement me")
+func (us *userStorage) CreateTx(ctx *context.Context, tx *sql.Tx, u *domain.User) error {
+	cols, values := (*User)(u).insertion()
+	query, args, err := db.Insert(users).Columns(cols...).Values(values...).Build()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ExecStatementWithTx(ctx.Context(), db.INSERT, tx, string(users), query, args...)
+	return created(u, result, err)
+}
+
+// created sets the generated id on the user and translates driver errors into application errors.
+func created(u *domain.User, result sql.Result, err error) error {
+	if err != nil {
+		if mysql.IsDuplicateEntry(err) {
+			return oops.Errorf(oops.E4xxCONFLICT, "user already exists [id: %d] [name: %s]", u.ID, u.Name)
+		}
+		return err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	u.ID = int(lastId)
+	return nil
+}
+
+func (us *userStorage) Get(ctx *context.Context, uid int) (domain.User, error) {
+	query, args, err := db.Select(id, name, age).From(users).Where(id, db.Equal, uid).Build()
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	var u domain.User
+	row := db.ExecQueryRow(ctx.Context(), us.storage, string(users), query, args...)
+	if err := row.Scan(u.Cols(nil)...); err != nil {
+		if err == sql.ErrNoRows {
+			return u, notFound(uid)
+		}
+		return u, err
+	}
+
+	return u, nil
+}
+
+func (us *userStorage) Update(ctx *context.Context, u domain.User) error {
+	query, args, err := db.Update(users).
+		Set(name, db.Equal, u.Name).
+		Set(age, db.Equal, u.Age).
+		Where(id, db.Equal, u.ID).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ExecStatement(ctx.Context(), us.storage, db.UPDATE, string(users), query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// MySQL reports zero affected rows when the values are unchanged, so tell that apart from a missing row.
+	// The check reads from the primary, since a replica may not have the row yet.
+	if affected == 0 {
+		found, err := us.exists(mysql.WithPrimary(ctx.Context()), u.ID)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return notFound(u.ID)
+		}
+	}
+
+	return nil
+}
+
+func (us *userStorage) Delete(ctx *context.Context, uid int) error {
+	query, args, err := db.Delete(users).Where(id, db.Equal, uid).Build()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ExecStatement(ctx.Context(), us.storage, db.DELETE, string(users), query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound(uid)
+	}
+
+	return nil
+}
+
+func (us *userStorage) Exists(ctx *context.Context, uid int) (bool, error) {
+	return us.exists(ctx.Context(), uid)
+}
+
+func (us *userStorage) exists(ctx stdcontext.Context, uid int) (bool, error) {
+	query, args, err := db.Select("1").From(users).Where(id, db.Equal, uid).Build()
+	if err != nil {
+		return false, err
+	}
+
+	var found int
+	row := db.ExecQueryRow(ctx, us.storage, string(users), query, args...)
+	if err := row.Scan(&found); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
 }
 
 func (us *userStorage) Search(ctx *context.Context, f Filters) (domain.UserPages, error) {
-	wheres, args := f.projections()
+	wheres := f.projections()
+	orders, err := f.orders()
+	if err != nil {
+		return domain.UserPages{}, err
+	}
 
-	sql := db.Select(f.Fields...).From(users)
+	sql := db.Select(f.Fields...).WithCounter().From(users)
+	sql.OrderBy(orders...)
 	sql.Limit(f.Offset, f.Limit)
-
-	for _, ko := range wheres {
-		sql.Where(ko.key, ko.Op)
+	for i, ko := range wheres {
+		w := sql.Where(ko.key, ko.Op, ko.values()...)
+		if i < len(wheres)-1 {
+			w.And()
+		}
 	}
 
-	query, _ := sql.Build()
+	query, args, err := sql.Build()
+	if err == db.SqlBuilderArgsMismatchErr {
+		return domain.UserPages{}, invalidFilter(err)
+	}
+	if err != nil {
+		return domain.UserPages{}, err
+	}
 
 	var up domain.UserPages
 	rows, err := db.ExecQuery(ctx.Context(), us.storage, string(users), query, args...)
@@ -61,22 +215,96 @@ func (us *userStorage) Search(ctx *context.Context, f Filters) (domain.UserPages
 	}
 	defer rows.Close()
 
+	total := 0
 	users := make(domain.Users, 0)
 	for rows.Next() {
 		user := new(domain.User)
-		if err := rows.Scan(user.Cols(f.Fields)...); err != nil {
+		if err := rows.Scan(append(user.Cols(f.Fields), &total)...); err != nil {
 			return up, err
 		}
 		users = append(users, *user)
 	}
 
+	if err := rows.Err(); err != nil {
+		return up, err
+	}
+
+	// A page past the last row carries no counter, so ask for it explicitly.
+	if len(users) == 0 && f.Offset > 0 {
+		if total, err = us.count(ctx, wheres); err != nil {
+			return up, err
+		}
+	}
+
 	up.Offset = f.Offset
 	up.Limit = f.Limit
-	up.Total = 1
+	up.Total = total
 	up.Users = users
 	return up, nil
 }
 
+func (us *userStorage) count(ctx *context.Context, wheres []KeyOperator) (int, error) {
+	sql := db.Select("count(*)").From(users)
+	for i, ko := range wheres {
+		w := sql.Where(ko.key, ko.Op, ko.values()...)
+		if i < len(wheres)-1 {
+			w.And()
+		}
+	}
+
+	query, args, err := sql.Build()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	row := db.ExecQueryRow(ctx.Context(), us.storage, string(users), query, args...)
+	if err := row.Scan(&total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (us *userStorage) CountByAge(ctx *context.Context, width, atLeast int) (domain.AgeBuckets, error) {
+	if width <= 0 {
+		return nil, invalidWidth(width)
+	}
+
+	// The width is a validated integer, so it can be rendered into the projection.
+	bucket := db.Column(fmt.Sprintf("FLOOR(%s / %d) * %d", age, width, width))
+	query, args, err := db.Select(bucket.As("bucket"), db.Count("*").As("total")).
+		From(users).
+		GroupBy(bucket).
+		Having(db.Count("*"), db.GreaterEqualsThan, atLeast).
+		OrderBy(db.Ascending("bucket")).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.ExecQuery(ctx.Context(), us.storage, string(users), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make(domain.AgeBuckets, 0)
+	for rows.Next() {
+		var b domain.AgeBucket
+		if err := rows.Scan(&b.From, &b.Total); err != nil {
+			return nil, err
+		}
+		b.To = b.From + width - 1
+		buckets = append(buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
 func (u *User) args() []interface{} {
 	args := make([]interface{}, 0)
 
@@ -95,31 +323,106 @@ func (u *User) args() []interface{} {
 	return args
 }
 
-func (f Filters) projections() ([]KeyOperator, []interface{}) {
-	args := make([]interface{}, 0)
+func notFound(id int) error {
+	return oops.Errorf(oops.E4xxNOTFOUND, "user not found [id: %d]", id)
+}
+
+// invalidFilter reports a filter binding a number of values its operator does not take.
+func invalidFilter(err error) error {
+	return oops.Errorf(oops.E4xxCLIENTSIDE, "invalid filter values [error: %s]", err.Error())
+}
+
+func invalidWidth(width int) error {
+	return oops.Errorf(oops.E4xxCLIENTSIDE, "invalid age bucket width [width: %d]", width)
+}
+
+func (u *User) insertion() ([]db.Column, []interface{}) {
+	cols := []db.Column{name, age}
+	args := []interface{}{u.Name, u.Age}
+
+	if u.ID > 0 {
+		cols = append([]db.Column{id}, cols...)
+		args = append([]interface{}{u.ID}, args...)
+	}
+
+	return cols, args
+}
+
+func (f Filters) projections() []KeyOperator {
 	ko := make([]KeyOperator, 0)
 
 	if f.Id.HasValue() {
 		f.Id.key = id
 		ko = append(ko, f.Id)
-		args = append(args, f.Id.Value)
 	}
 
 	if f.Name.HasValue() {
 		f.Name.key = name
 		ko = append(ko, f.Name)
-		args = append(args, f.Name.Value)
 	}
 
 	if f.Age.HasValue() {
 		f.Age.key = age
 		ko = append(ko, f.Age)
-		args = append(args, f.Age.Value)
 	}
 
-	return ko, args
+	return ko
+}
+
+// orders validates the requested sort and appends the id, so pages never overlap on ties.
+func (f Filters) orders() ([]db.Order, error) {
+	orders := make([]db.Order, 0, len(f.Sort)+1)
+	byId := false
+	for _, o := range f.Sort {
+		switch o.Column {
+		case id:
+			byId = true
+		case name, age:
+		default:
+			return nil, oops.Errorf(oops.E4xxCLIENTSIDE, "unknown sort field [field: %s]", o.Column)
+		}
+
+		if o.Direction != "" && o.Direction != db.Asc && o.Direction != db.Desc {
+			return nil, oops.Errorf(oops.E4xxCLIENTSIDE, "invalid sort direction [field: %s] [direction: %s]", o.Column, strings.TrimSpace(string(o.Direction)))
+		}
+
+		if o.Nulls != "" && o.Nulls != db.NullsFirst && o.Nulls != db.NullsLast {
+			return nil, oops.Errorf(oops.E4xxCLIENTSIDE, "invalid sort nulls [field: %s] [nulls: %s]", o.Column, o.Nulls)
+		}
+		orders = append(orders, o)
+	}
+
+	if !byId {
+		orders = append(orders, db.Ascending(id))
+	}
+	return orders, nil
 }
 
 func (ko KeyOperator) HasValue() bool {
+	if ko.Op == db.IsNull || ko.Op == db.IsNotNull {
+		return true
+	}
 	return ko.Value != nil && len(ko.Op) > 0
 }
+
+// values returns the arguments bound by the operator. IN, NOT IN and BETWEEN take a slice as Value,
+// and the null checks take none.
+func (ko KeyOperator) values() []interface{} {
+	switch ko.Op {
+	case db.IsNull, db.IsNotNull:
+		return nil
+	case db.In, db.NotIn, db.Between:
+		v := reflect.ValueOf(ko.Value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return []interface{}{ko.Value}
+		}
+
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+		return values
+	default:
+		return []interface{}{ko.Value}
+	}
+}
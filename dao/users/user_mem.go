@@ -4,41 +4,550 @@ import (
 	"fmt"
 	"go-dao-pattern/domain"
 	"go-dao-pattern/pkg/context"
+	oops "go-dao-pattern/pkg/errors"
 	"go-dao-pattern/pkg/storage/memory"
+	"go-dao-pattern/pkg/storage/mysql/db"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 // Ensure type implements interface.
 var _ DataAccess = (*userMemory)(nil)
 
+const prefix = "users:"
+
 type userMemory struct {
 	storage *memory.StorageClient
+	seq     int64
 }
 
 func (u *userMemory) Search(context *context.Context, filters Filters) (domain.UserPages, error) {
-	key := fmt.Sprintf("%s-%s", fmt.Sprint(filters.Id.Value), filters.Name.Value)
-	data, err := u.storage.Get(context, key)
+	wheres := filters.projections()
+	orders, err := filters.orders()
+	if err != nil {
+		return domain.UserPages{}, err
+	}
+
+	patterns := compile(wheres)
+	matches := make(domain.Users, 0)
+	visit := func(_ string, value interface{}) bool {
+		user := value.(domain.User)
+
+		found, e := match(user, wheres, patterns)
+		if e != nil {
+			err = e
+			return false
+		}
+
+		if found {
+			matches = append(matches, user)
+		}
+		return true
+	}
+
+	// Narrow the candidates through the index of the first filter that has one, then check them all.
+	if ko, r, indexed := indexRange(wheres); indexed {
+		if e := u.storage.Range(context, string(ko.key), r, visit); e != nil {
+			return domain.UserPages{}, e
+		}
+	} else {
+		u.storage.Scan(context, prefix, visit)
+	}
+
 	if err != nil {
 		return domain.UserPages{}, err
 	}
 
-	users := make(domain.Users, 1)
-	users[0] = data.(domain.User)
+	sort.Slice(matches, func(i, j int) bool {
+		return less(matches[i], matches[j], orders)
+	})
+
+	page := paginate(matches, filters.Offset, filters.Limit)
+	users := make(domain.Users, len(page))
+	for i, user := range page {
+		users[i] = project(user, filters.Fields)
+	}
+
 	up := domain.UserPages{
-		Limit:  0,
-		Offset: 0,
-		Total:  0,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+		Total:  len(matches),
 		Users:  users,
 	}
-	return up, err
+	return up, nil
+}
+
+func (u *userMemory) BeginTx(context *context.Context) *memory.Tx {
+	return u.storage.Begin(context)
+}
+
+func (u *userMemory) Create(context *context.Context, user *domain.User) error {
+	tx := u.BeginTx(context)
+	if err := u.CreateTx(context, tx, user); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if err == memory.TxConflictErr {
+			return oops.Errorf(oops.E4xxCONFLICT, "user already exists [id: %d] [name: %s]", user.ID, user.Name)
+		}
+		return err
+	}
+	return nil
 }
 
-func (u *userMemory) Create(context *context.Context, user domain.User) error {
-	key := fmt.Sprintf("%s-%s", fmt.Sprint(user.ID), user.Name)
-	return u.storage.Save(context, key, user)
+func (u *userMemory) CreateTx(context *context.Context, tx *memory.Tx, user *domain.User) error {
+	if user.ID == 0 {
+		user.ID = int(atomic.AddInt64(&u.seq, 1))
+	}
+
+	found, err := tx.Exists(context, key(user.ID))
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return oops.Errorf(oops.E4xxCONFLICT, "user already exists [id: %d] [name: %s]", user.ID, user.Name)
+	}
+
+	if err := tx.Save(context, key(user.ID), *user); err != nil {
+		return err
+	}
+
+	// Like AUTO_INCREMENT, an explicit id moves the sequence past it, even if the transaction rolls back.
+	for {
+		seq := atomic.LoadInt64(&u.seq)
+		if int64(user.ID) <= seq || atomic.CompareAndSwapInt64(&u.seq, seq, int64(user.ID)) {
+			return nil
+		}
+	}
+}
+
+func (u *userMemory) Get(context *context.Context, id int) (domain.User, error) {
+	data, err := u.storage.Get(context, key(id))
+	if err != nil {
+		if err == memory.DataNotFoundErr {
+			return domain.User{}, notFound(id)
+		}
+		return domain.User{}, err
+	}
+	return data.(domain.User), nil
+}
+
+// Update checks the user exists and saves it in one transaction, so a concurrent Delete cannot be undone.
+// A conflict means another writer changed the user meanwhile, and trying again settles the update the way
+// the MySQL row lock does: not found if it was deleted, the last write wins otherwise.
+func (u *userMemory) Update(context *context.Context, user domain.User) error {
+	for {
+		tx := u.BeginTx(context)
+		found, err := tx.Exists(context, key(user.ID))
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if !found {
+			_ = tx.Rollback()
+			return notFound(user.ID)
+		}
+
+		if err := tx.Save(context, key(user.ID), user); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != memory.TxConflictErr {
+			return err
+		}
+	}
+}
+
+func (u *userMemory) Delete(context *context.Context, id int) error {
+	if err := u.storage.Delete(context, key(id)); err != nil {
+		if err == memory.DataNotFoundErr {
+			return notFound(id)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *userMemory) Exists(context *context.Context, id int) (bool, error) {
+	return u.storage.Exists(context, key(id)), nil
+}
+
+func (u *userMemory) CountByAge(context *context.Context, width, atLeast int) (domain.AgeBuckets, error) {
+	if width <= 0 {
+		return nil, invalidWidth(width)
+	}
+
+	totals := make(map[int]int)
+	u.storage.Scan(context, prefix, func(_ string, value interface{}) bool {
+		totals[bucket(value.(domain.User).Age, width)]++
+		return true
+	})
+
+	buckets := make(domain.AgeBuckets, 0, len(totals))
+	for from, total := range totals {
+		if total >= atLeast {
+			buckets = append(buckets, domain.AgeBucket{From: from, To: from + width - 1, Total: total})
+		}
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].From < buckets[j].From
+	})
+	return buckets, nil
 }
 
 func NewUserMemoryStorage() *userMemory {
+	storage := memory.InitConnection()
+
+	// The prefix is fixed and the names are unique, so creating the indexes cannot fail.
+	_ = storage.CreateIndex(string(id), prefix, func(_ string, v interface{}) (interface{}, bool) {
+		return v.(domain.User).ID, true
+	})
+	_ = storage.CreateIndex(string(age), prefix, func(_ string, v interface{}) (interface{}, bool) {
+		return v.(domain.User).Age, true
+	})
+	_ = storage.CreateIndex(string(name), prefix, func(_ string, v interface{}) (interface{}, bool) {
+		return strings.ToLower(v.(domain.User).Name), true
+	})
+
 	return &userMemory{
-		storage: memory.InitConnection(),
+		storage: storage,
+	}
+}
+
+func key(id int) string {
+	return prefix + strconv.Itoa(id)
+}
+
+// compile returns the matcher of every LIKE condition, at the position of the condition, so that
+// a search compiles each pattern once rather than once per user.
+func compile(wheres []KeyOperator) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(wheres))
+	for i, ko := range wheres {
+		if ko.Op == db.Like || ko.Op == db.NotLike {
+			patterns[i] = like(fmt.Sprint(ko.Value))
+		}
+	}
+	return patterns
+}
+
+// match reports whether the user satisfies every condition, mirroring the AND chain sent to MySQL.
+// patterns holds the compiled LIKE patterns of the conditions.
+func match(u domain.User, wheres []KeyOperator, patterns []*regexp.Regexp) (bool, error) {
+	for i, ko := range wheres {
+		found, err := test(u, ko, patterns[i])
+		if err != nil {
+			return false, err
+		}
+
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// test evaluates a single condition against the user. pattern is the compiled LIKE pattern of the
+// condition, nil for other operators.
+func test(u domain.User, ko KeyOperator, pattern *regexp.Regexp) (bool, error) {
+	switch ko.Op {
+	case db.IsNull, db.IsNotNull:
+		// Every column is NOT NULL.
+		return ko.Op == db.IsNotNull, nil
+	case db.In, db.NotIn:
+		for _, v := range ko.values() {
+			c, err := compare(u, ko.key, v)
+			if err != nil {
+				return false, err
+			}
+
+			if c == 0 {
+				return ko.Op == db.In, nil
+			}
+		}
+		return ko.Op == db.NotIn, nil
+	case db.Between:
+		bounds := ko.values()
+		if len(bounds) != 2 {
+			return false, oops.Errorf(oops.E4xxCLIENTSIDE, "between takes two values [field: %s] [value: %v]", ko.key, ko.Value)
+		}
+
+		low, err := compare(u, ko.key, bounds[0])
+		if err != nil {
+			return false, err
+		}
+
+		high, err := compare(u, ko.key, bounds[1])
+		if err != nil {
+			return false, err
+		}
+		return low >= 0 && high <= 0, nil
+	case db.Like, db.NotLike:
+		field, err := text(u, ko.key)
+		if err != nil {
+			return false, err
+		}
+		return pattern.MatchString(field) == (ko.Op == db.Like), nil
+	}
+
+	c, err := compare(u, ko.key, ko.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch ko.Op {
+	case db.Equal:
+		return c == 0, nil
+	case db.NotEqual:
+		return c != 0, nil
+	case db.GreaterThan:
+		return c > 0, nil
+	case db.LessThan:
+		return c < 0, nil
+	case db.GreaterEqualsThan:
+		return c >= 0, nil
+	case db.LessEqualsThan:
+		return c <= 0, nil
+	default:
+		return false, oops.Errorf(oops.E4xxCLIENTSIDE, "unsupported operator [op: %s]", strings.TrimSpace(string(ko.Op)))
+	}
+}
+
+// indexRange translates the first filter that can use an index into a range over it.
+// Filters whose value cannot be converted are left to match, which reports the error.
+func indexRange(wheres []KeyOperator) (KeyOperator, memory.Range, bool) {
+	for _, ko := range wheres {
+		var r memory.Range
+		switch ko.Op {
+		case db.Equal, db.GreaterThan, db.GreaterEqualsThan, db.LessThan, db.LessEqualsThan:
+			value, ok := indexValue(ko.key, ko.Value)
+			if !ok {
+				continue
+			}
+
+			switch ko.Op {
+			case db.Equal:
+				r.From = &memory.Bound{Value: value, Inclusive: true}
+				r.To = &memory.Bound{Value: value, Inclusive: true}
+			case db.GreaterThan:
+				r.From = &memory.Bound{Value: value}
+			case db.GreaterEqualsThan:
+				r.From = &memory.Bound{Value: value, Inclusive: true}
+			case db.LessThan:
+				r.To = &memory.Bound{Value: value}
+			case db.LessEqualsThan:
+				r.To = &memory.Bound{Value: value, Inclusive: true}
+			}
+		case db.Between:
+			bounds := ko.values()
+			if len(bounds) != 2 {
+				continue
+			}
+
+			low, ok := indexValue(ko.key, bounds[0])
+			if !ok {
+				continue
+			}
+
+			high, ok := indexValue(ko.key, bounds[1])
+			if !ok {
+				continue
+			}
+
+			r.From = &memory.Bound{Value: low, Inclusive: true}
+			r.To = &memory.Bound{Value: high, Inclusive: true}
+		case db.Like:
+			// Only a name pattern with a literal prefix narrows the lookup, to the names starting with it.
+			p := strings.ToLower(likePrefix(fmt.Sprint(ko.Value)))
+			if ko.key != name || len(p) == 0 {
+				continue
+			}
+
+			// No UTF-8 encoded name contains 0xff, so it sorts after every name sharing the prefix.
+			r.From = &memory.Bound{Value: p, Inclusive: true}
+			r.To = &memory.Bound{Value: p + "\xff"}
+		default:
+			continue
+		}
+		return ko, r, true
+	}
+	return KeyOperator{}, memory.Range{}, false
+}
+
+// indexValue converts a filter value into the form the index of the field stores.
+func indexValue(key db.Column, value interface{}) (interface{}, bool) {
+	switch key {
+	case id, age:
+		v, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return nil, false
+		}
+		return v, true
+	case name:
+		return strings.ToLower(fmt.Sprint(value)), true
+	default:
+		return nil, false
+	}
+}
+
+// compare returns the ordering of the user field against the filter value.
+// Names are compared case-insensitively, like the default MySQL collation.
+func compare(u domain.User, key db.Column, value interface{}) (int, error) {
+	switch key {
+	case id, age:
+		field := u.ID
+		if key == age {
+			field = u.Age
+		}
+
+		v, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil {
+			return 0, oops.Errorf(oops.E4xxCLIENTSIDE, "invalid numeric filter [field: %s] [value: %v]", key, value)
+		}
+
+		switch {
+		case float64(field) < v:
+			return -1, nil
+		case float64(field) > v:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case name:
+		return strings.Compare(strings.ToLower(u.Name), strings.ToLower(fmt.Sprint(value))), nil
+	default:
+		return 0, oops.Errorf(oops.E4xxCLIENTSIDE, "unknown filter field [field: %s]", key)
+	}
+}
+
+// text returns the user field as MySQL renders it for a LIKE comparison.
+func text(u domain.User, key db.Column) (string, error) {
+	switch key {
+	case id:
+		return strconv.Itoa(u.ID), nil
+	case age:
+		return strconv.Itoa(u.Age), nil
+	case name:
+		return u.Name, nil
+	default:
+		return "", oops.Errorf(oops.E4xxCLIENTSIDE, "unknown filter field [field: %s]", key)
+	}
+}
+
+// like compiles a LIKE pattern, where % matches any run of characters, _ exactly one and a backslash
+// escapes the next character. The match ignores case, like the default MySQL collation.
+func like(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			sb.WriteString(".*")
+		case r == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	// A trailing backslash stands for itself.
+	if escaped {
+		sb.WriteString(regexp.QuoteMeta("\\"))
+	}
+
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// likePrefix returns the literal characters a LIKE pattern starts with, up to its first wildcard.
+func likePrefix(pattern string) string {
+	end := strings.IndexAny(pattern, "%_\\")
+	if end < 0 {
+		return pattern
+	}
+	return pattern[:end]
+}
+
+// less orders users the way the ORDER BY sent to MySQL does. Every column is NOT NULL, so the
+// nulls order has nothing to place.
+func less(a, b domain.User, orders []db.Order) bool {
+	for _, o := range orders {
+		// The sort fields were validated, so neither lookup can fail.
+		v, _ := text(b, o.Column)
+		c, _ := compare(a, o.Column, v)
+		if c == 0 {
+			continue
+		}
+
+		if o.Direction == db.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+// bucket returns the first age of the bucket holding age, rounding down like FLOOR.
+func bucket(age, width int) int {
+	b := age / width
+	if age%width != 0 && age < 0 {
+		b--
+	}
+	return b * width
+}
+
+// paginate applies offset and limit the same way the select builder renders LIMIT.
+func paginate(users domain.Users, offset, limit int) domain.Users {
+	if offset < 0 || limit < 0 {
+		return users
+	}
+
+	if limit == 0 {
+		limit = db.DefaultMaxPages
+	}
+
+	if offset >= len(users) {
+		return domain.Users{}
+	}
+
+	end := offset + limit
+	if end > len(users) {
+		end = len(users)
+	}
+	return users[offset:end]
+}
+
+// project keeps only the requested fields, leaving the rest zeroed as a MySQL projection would.
+func project(u domain.User, fields []db.Column) domain.User {
+	if len(fields) == 0 {
+		return u
+	}
+
+	var p domain.User
+	for _, f := range fields {
+		switch f {
+		case id:
+			p.ID = u.ID
+		case name:
+			p.Name = u.Name
+		case age:
+			p.Age = u.Age
+		}
 	}
+	return p
 }
@@ -0,0 +1,101 @@
+package users_test
+
+import (
+	"sync"
+	"testing"
+
+	"go-dao-pattern/dao/users"
+	"go-dao-pattern/dao/users/userstest"
+	"go-dao-pattern/domain"
+	"go-dao-pattern/pkg/context"
+	"go-dao-pattern/pkg/storage/mysql/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserMemory_Conformance(t *testing.T) {
+	userstest.Run(t, func(t *testing.T) users.DataAccess {
+		return users.NewUserMemoryStorage()
+	})
+}
+
+func TestUserMemory_CreateTx(t *testing.T) {
+	ctx := context.NewBackgroundContext()
+	storage := users.NewUserMemoryStorage()
+	u := &domain.User{Name: "ana", Age: 30}
+
+	tx := storage.BeginTx(ctx)
+	assert.Nil(t, storage.CreateTx(ctx, tx, u))
+
+	found, err := storage.Exists(ctx, u.ID)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, tx.Commit())
+	found, err = storage.Exists(ctx, u.ID)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	// A rolled back creation still consumes its id.
+	tx = storage.BeginTx(ctx)
+	rolledBack := &domain.User{Name: "bob"}
+	assert.Nil(t, storage.CreateTx(ctx, tx, rolledBack))
+	assert.Nil(t, tx.Rollback())
+
+	next := &domain.User{Name: "carla"}
+	assert.Nil(t, storage.Create(ctx, next))
+	assert.Equal(t, rolledBack.ID+1, next.ID)
+}
+
+// An update racing a delete either lands first or finds the user gone, but never brings it back.
+func TestUserMemory_UpdateDelete(t *testing.T) {
+	ctx := context.NewBackgroundContext()
+	storage := users.NewUserMemoryStorage()
+
+	for i := 0; i < 200; i++ {
+		u := &domain.User{Name: "ana", Age: 30}
+		require.Nil(t, storage.Create(ctx, u))
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = storage.Update(ctx, domain.User{ID: u.ID, Name: "bob", Age: 31})
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, storage.Delete(ctx, u.ID))
+		}()
+		wg.Wait()
+
+		found, err := storage.Exists(ctx, u.ID)
+		assert.Nil(t, err)
+		assert.False(t, found)
+	}
+}
+
+func TestUserMemory_SearchLike(t *testing.T) {
+	ctx := context.NewBackgroundContext()
+	storage := users.NewUserMemoryStorage()
+	for _, n := range []string{"Ana", "an%", "andre", "bob"} {
+		assert.Nil(t, storage.Create(ctx, &domain.User{Name: n, Age: 30}))
+	}
+
+	search := func(pattern string) []string {
+		up, err := storage.Search(ctx, users.Filters{Name: users.KeyOperator{Op: db.Like, Value: pattern}})
+		assert.Nil(t, err)
+
+		out := make([]string, len(up.Users))
+		for i, u := range up.Users {
+			out[i] = u.Name
+		}
+		return out
+	}
+
+	// Patterns ignore case, like the default MySQL collation.
+	assert.Equal(t, []string{"Ana", "an%", "andre"}, search("AN%"))
+	assert.Equal(t, []string{"Ana", "an%"}, search("an_"))
+	assert.Equal(t, []string{"an%"}, search(`an\%`))
+	assert.Equal(t, []string{"andre"}, search("%dr%"))
+}
@@ -0,0 +1,393 @@
+package userstest
+
+import (
+	"fmt"
+	"testing"
+
+	"go-dao-pattern/dao/users"
+	"go-dao-pattern/domain"
+	"go-dao-pattern/pkg/context"
+	oops "go-dao-pattern/pkg/errors"
+	"go-dao-pattern/pkg/storage/mysql/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty users.DataAccess. It is called once per case, so every case starts from a clean store.
+type Factory func(t *testing.T) users.DataAccess
+
+// Run exercises the users.DataAccess contract against the backend built by the factory.
+// Every backend selectable through users.InitDataAccess is expected to pass it.
+func Run(t *testing.T, factory Factory) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, da users.DataAccess)
+	}{
+		{"CreateAssignsId", testCreateAssignsId},
+		{"CreateWithId", testCreateWithId},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"GetNotFound", testGetNotFound},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"Exists", testExists},
+		{"SearchOperators", testSearchOperators},
+		{"SearchAllFilters", testSearchAllFilters},
+		{"SearchNoMatch", testSearchNoMatch},
+		{"SearchProjection", testSearchProjection},
+		{"SearchPagination", testSearchPagination},
+		{"SearchSort", testSearchSort},
+		{"SearchSortErrors", testSearchSortErrors},
+		{"SearchFilterErrors", testSearchFilterErrors},
+		{"CountByAge", testCountByAge},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, factory(t))
+		})
+	}
+}
+
+func testCreateAssignsId(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	first := domain.User{Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &first))
+	assert.Greater(t, first.ID, 0)
+
+	second := domain.User{Name: "mariana", Age: 35}
+	require.Nil(t, da.Create(ctx, &second))
+	assert.Greater(t, second.ID, first.ID)
+
+	got, err := da.Get(ctx, first.ID)
+	require.Nil(t, err)
+	assert.Equal(t, first, got)
+}
+
+func testCreateWithId(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	explicit := domain.User{ID: 42, Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &explicit))
+	assert.Equal(t, 42, explicit.ID)
+
+	next := domain.User{Name: "mariana", Age: 35}
+	require.Nil(t, da.Create(ctx, &next))
+	assert.Greater(t, next.ID, 42)
+
+	got, err := da.Get(ctx, 42)
+	require.Nil(t, err)
+	assert.Equal(t, explicit, got)
+}
+
+func testCreateDuplicate(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	user := domain.User{ID: 1, Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &user))
+
+	duplicate := domain.User{ID: 1, Name: "mariana", Age: 35}
+	err := da.Create(ctx, &duplicate)
+	assert.True(t, oops.Is(oops.E4xxCONFLICT, err), "expected conflict, got %v", err)
+
+	got, err := da.Get(ctx, 1)
+	require.Nil(t, err)
+	assert.Equal(t, user, got)
+}
+
+func testGetNotFound(t *testing.T, da users.DataAccess) {
+	_, err := da.Get(context.NewBackgroundContext(), 1)
+	assert.True(t, oops.Is(oops.E4xxNOTFOUND, err), "expected not found, got %v", err)
+}
+
+func testUpdate(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	user := domain.User{Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &user))
+
+	user.Name = "leo"
+	user.Age = 39
+	require.Nil(t, da.Update(ctx, user))
+
+	got, err := da.Get(ctx, user.ID)
+	require.Nil(t, err)
+	assert.Equal(t, user, got)
+
+	// Writing the same values again is not a missing row.
+	assert.Nil(t, da.Update(ctx, user))
+}
+
+func testUpdateNotFound(t *testing.T, da users.DataAccess) {
+	err := da.Update(context.NewBackgroundContext(), domain.User{ID: 1, Name: "leonardo", Age: 38})
+	assert.True(t, oops.Is(oops.E4xxNOTFOUND, err), "expected not found, got %v", err)
+}
+
+func testDelete(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	user := domain.User{Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &user))
+	require.Nil(t, da.Delete(ctx, user.ID))
+
+	_, err := da.Get(ctx, user.ID)
+	assert.True(t, oops.Is(oops.E4xxNOTFOUND, err), "expected not found, got %v", err)
+}
+
+func testDeleteNotFound(t *testing.T, da users.DataAccess) {
+	err := da.Delete(context.NewBackgroundContext(), 1)
+	assert.True(t, oops.Is(oops.E4xxNOTFOUND, err), "expected not found, got %v", err)
+}
+
+func testExists(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+
+	user := domain.User{Name: "leonardo", Age: 38}
+	require.Nil(t, da.Create(ctx, &user))
+
+	found, err := da.Exists(ctx, user.ID)
+	require.Nil(t, err)
+	assert.True(t, found)
+
+	found, err = da.Exists(ctx, user.ID+1)
+	require.Nil(t, err)
+	assert.False(t, found)
+}
+
+func testSearchOperators(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 5)
+
+	cases := []struct {
+		name     string
+		filters  users.Filters
+		expected []string
+	}{
+		{"IdEqual", users.Filters{Id: users.KeyOperator{Op: db.Equal, Value: 3}}, []string{"user-3"}},
+		{"IdGreaterThan", users.Filters{Id: users.KeyOperator{Op: db.GreaterThan, Value: 3}}, []string{"user-4", "user-5"}},
+		{"IdLessThan", users.Filters{Id: users.KeyOperator{Op: db.LessThan, Value: 3}}, []string{"user-1", "user-2"}},
+		{"IdGreaterEqualsThan", users.Filters{Id: users.KeyOperator{Op: db.GreaterEqualsThan, Value: 4}}, []string{"user-4", "user-5"}},
+		{"IdLessEqualsThan", users.Filters{Id: users.KeyOperator{Op: db.LessEqualsThan, Value: 2}}, []string{"user-1", "user-2"}},
+		{"AgeEqual", users.Filters{Age: users.KeyOperator{Op: db.Equal, Value: 30}}, []string{"user-3"}},
+		{"AgeGreaterThan", users.Filters{Age: users.KeyOperator{Op: db.GreaterThan, Value: 30}}, []string{"user-4", "user-5"}},
+		{"AgeLessThan", users.Filters{Age: users.KeyOperator{Op: db.LessThan, Value: 30}}, []string{"user-1", "user-2"}},
+		{"AgeGreaterEqualsThan", users.Filters{Age: users.KeyOperator{Op: db.GreaterEqualsThan, Value: 30}}, []string{"user-3", "user-4", "user-5"}},
+		{"AgeLessEqualsThan", users.Filters{Age: users.KeyOperator{Op: db.LessEqualsThan, Value: 30}}, []string{"user-1", "user-2", "user-3"}},
+		{"NameEqual", users.Filters{Name: users.KeyOperator{Op: db.Equal, Value: "user-2"}}, []string{"user-2"}},
+		{"NameGreaterThan", users.Filters{Name: users.KeyOperator{Op: db.GreaterThan, Value: "user-3"}}, []string{"user-4", "user-5"}},
+		{"NameLessThan", users.Filters{Name: users.KeyOperator{Op: db.LessThan, Value: "user-3"}}, []string{"user-1", "user-2"}},
+		{"NameGreaterEqualsThan", users.Filters{Name: users.KeyOperator{Op: db.GreaterEqualsThan, Value: "user-5"}}, []string{"user-5"}},
+		{"NameLessEqualsThan", users.Filters{Name: users.KeyOperator{Op: db.LessEqualsThan, Value: "user-1"}}, []string{"user-1"}},
+		{"IdNotEqual", users.Filters{Id: users.KeyOperator{Op: db.NotEqual, Value: 3}}, []string{"user-1", "user-2", "user-4", "user-5"}},
+		{"IdIn", users.Filters{Id: users.KeyOperator{Op: db.In, Value: []int{1, 3, 9}}}, []string{"user-1", "user-3"}},
+		{"IdInEmpty", users.Filters{Id: users.KeyOperator{Op: db.In, Value: []int{}}}, []string{}},
+		{"IdNotIn", users.Filters{Id: users.KeyOperator{Op: db.NotIn, Value: []int{2, 4}}}, []string{"user-1", "user-3", "user-5"}},
+		{"AgeBetween", users.Filters{Age: users.KeyOperator{Op: db.Between, Value: []int{20, 40}}}, []string{"user-2", "user-3", "user-4"}},
+		{"NameIn", users.Filters{Name: users.KeyOperator{Op: db.In, Value: []string{"user-2", "user-5"}}}, []string{"user-2", "user-5"}},
+		{"NameStartsWith", users.Filters{Name: users.KeyOperator{Op: db.Like, Value: "user-%"}}, []string{"user-1", "user-2", "user-3", "user-4", "user-5"}},
+		{"NameLikeSingle", users.Filters{Name: users.KeyOperator{Op: db.Like, Value: "user-_"}}, []string{"user-1", "user-2", "user-3", "user-4", "user-5"}},
+		{"NameLikeSuffix", users.Filters{Name: users.KeyOperator{Op: db.Like, Value: "%-3"}}, []string{"user-3"}},
+		{"NameNotLike", users.Filters{Name: users.KeyOperator{Op: db.NotLike, Value: "%1"}}, []string{"user-2", "user-3", "user-4", "user-5"}},
+		{"NameBetween", users.Filters{Name: users.KeyOperator{Op: db.Between, Value: []string{"user-2", "user-3"}}}, []string{"user-2", "user-3"}},
+		{"NameIsNull", users.Filters{Name: users.KeyOperator{Op: db.IsNull}}, []string{}},
+		{"NameIsNotNull", users.Filters{Name: users.KeyOperator{Op: db.IsNotNull}}, []string{"user-1", "user-2", "user-3", "user-4", "user-5"}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			up, err := da.Search(ctx, c.filters)
+			require.Nil(t, err)
+			assert.Equal(t, c.expected, names(up.Users))
+			assert.Equal(t, len(c.expected), up.Total)
+		})
+	}
+}
+
+func testSearchAllFilters(t *testing.T, da users.DataAccess) {
+	seed(t, da, 5)
+
+	f := users.Filters{
+		Id:   users.KeyOperator{Op: db.GreaterThan, Value: 1},
+		Name: users.KeyOperator{Op: db.LessThan, Value: "user-5"},
+		Age:  users.KeyOperator{Op: db.GreaterEqualsThan, Value: 30},
+	}
+
+	up, err := da.Search(context.NewBackgroundContext(), f)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"user-3", "user-4"}, names(up.Users))
+	assert.Equal(t, 2, up.Total)
+}
+
+func testSearchNoMatch(t *testing.T, da users.DataAccess) {
+	seed(t, da, 3)
+
+	f := users.Filters{Age: users.KeyOperator{Op: db.GreaterThan, Value: 100}}
+
+	up, err := da.Search(context.NewBackgroundContext(), f)
+	require.Nil(t, err)
+	assert.Empty(t, up.Users)
+	assert.Equal(t, 0, up.Total)
+}
+
+func testSearchProjection(t *testing.T, da users.DataAccess) {
+	seed(t, da, 2)
+
+	f := users.Filters{
+		Fields: []db.Column{"name"},
+		Id:     users.KeyOperator{Op: db.Equal, Value: 2},
+	}
+
+	up, err := da.Search(context.NewBackgroundContext(), f)
+	require.Nil(t, err)
+	assert.Equal(t, domain.Users{{Name: "user-2"}}, up.Users)
+
+	f.Fields = []db.Column{"id", "age"}
+	up, err = da.Search(context.NewBackgroundContext(), f)
+	require.Nil(t, err)
+	assert.Equal(t, domain.Users{{ID: 2, Age: 20}}, up.Users)
+}
+
+func testSearchPagination(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 25)
+
+	cases := []struct {
+		name     string
+		offset   int
+		limit    int
+		expected []int
+	}{
+		{"FirstPage", 0, 10, ids(1, 10)},
+		{"MiddlePage", 10, 10, ids(11, 20)},
+		{"LastPage", 20, 10, ids(21, 25)},
+		{"PastLastPage", 30, 10, ids(1, 0)},
+		{"DefaultLimit", 5, 0, ids(6, 15)},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			up, err := da.Search(ctx, users.Filters{Offset: c.offset, Limit: c.limit})
+			require.Nil(t, err)
+
+			got := make([]int, len(up.Users))
+			for i, u := range up.Users {
+				got[i] = u.ID
+			}
+
+			assert.Equal(t, c.expected, got)
+			assert.Equal(t, 25, up.Total)
+			assert.Equal(t, c.offset, up.Offset)
+			assert.Equal(t, c.limit, up.Limit)
+		})
+	}
+}
+
+func testSearchSort(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 5)
+	require.Nil(t, da.Create(ctx, &domain.User{ID: 6, Name: "user-0", Age: 30}))
+
+	cases := []struct {
+		name     string
+		sort     []db.Order
+		offset   int
+		limit    int
+		expected []string
+	}{
+		{"DefaultById", nil, 0, 10, []string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-0"}},
+		{"ByNameAscending", []db.Order{db.Ascending("name")}, 0, 10, []string{"user-0", "user-1", "user-2", "user-3", "user-4", "user-5"}},
+		{"ByIdDescending", []db.Order{db.Descending("id")}, 0, 3, []string{"user-0", "user-5", "user-4"}},
+		{"ByAgeDescendingTiesById", []db.Order{db.Descending("age")}, 0, 10, []string{"user-5", "user-4", "user-3", "user-0", "user-2", "user-1"}},
+		{"ByAgeThenName", []db.Order{db.Ascending("age"), db.Ascending("name")}, 2, 2, []string{"user-0", "user-3"}},
+		{"ByAgeNullsLast", []db.Order{db.Descending("age").NullsLast(), db.Descending("name").NullsFirst()}, 2, 2, []string{"user-3", "user-0"}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			up, err := da.Search(ctx, users.Filters{Sort: c.sort, Offset: c.offset, Limit: c.limit})
+			require.Nil(t, err)
+			assert.Equal(t, c.expected, names(up.Users))
+			assert.Equal(t, 6, up.Total)
+		})
+	}
+}
+
+func testSearchSortErrors(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 1)
+
+	_, err := da.Search(ctx, users.Filters{Sort: []db.Order{db.Ascending("password")}})
+	assert.True(t, oops.Is(oops.E4xxCLIENTSIDE, err), "expected client side error, got %v", err)
+
+	_, err = da.Search(ctx, users.Filters{Sort: []db.Order{{Column: "age", Direction: "sideways"}}})
+	assert.True(t, oops.Is(oops.E4xxCLIENTSIDE, err), "expected client side error, got %v", err)
+
+	_, err = da.Search(ctx, users.Filters{Sort: []db.Order{{Column: "age", Nulls: "middle"}}})
+	assert.True(t, oops.Is(oops.E4xxCLIENTSIDE, err), "expected client side error, got %v", err)
+}
+
+func testSearchFilterErrors(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 1)
+
+	_, err := da.Search(ctx, users.Filters{Age: users.KeyOperator{Op: db.Between, Value: []int{1}}})
+	assert.True(t, oops.Is(oops.E4xxCLIENTSIDE, err), "expected client side error, got %v", err)
+}
+
+func testCountByAge(t *testing.T, da users.DataAccess) {
+	ctx := context.NewBackgroundContext()
+	seed(t, da, 5)
+	require.Nil(t, da.Create(ctx, &domain.User{Name: "user-6", Age: 35}))
+	require.Nil(t, da.Create(ctx, &domain.User{Name: "user-7", Age: 39}))
+
+	buckets, err := da.CountByAge(ctx, 20, 0)
+	require.Nil(t, err)
+	assert.Equal(t, domain.AgeBuckets{
+		{From: 0, To: 19, Total: 1},
+		{From: 20, To: 39, Total: 4},
+		{From: 40, To: 59, Total: 2},
+	}, buckets)
+
+	buckets, err = da.CountByAge(ctx, 10, 2)
+	require.Nil(t, err)
+	assert.Equal(t, domain.AgeBuckets{{From: 30, To: 39, Total: 3}}, buckets)
+
+	buckets, err = da.CountByAge(ctx, 10, 10)
+	require.Nil(t, err)
+	assert.Empty(t, buckets)
+
+	_, err = da.CountByAge(ctx, 0, 0)
+	assert.True(t, oops.Is(oops.E4xxCLIENTSIDE, err), "expected client side error, got %v", err)
+}
+
+// seed creates n users with ids 1..n, names user-1..user-n and ages 10..n*10.
+func seed(t *testing.T, da users.DataAccess, n int) {
+	ctx := context.NewBackgroundContext()
+	for i := 1; i <= n; i++ {
+		user := domain.User{ID: i, Name: fmt.Sprintf("user-%d", i), Age: i * 10}
+		require.Nil(t, da.Create(ctx, &user))
+	}
+}
+
+func names(us domain.Users) []string {
+	out := make([]string, len(us))
+	for i, u := range us {
+		out[i] = u.Name
+	}
+	return out
+}
+
+func ids(from, to int) []int {
+	out := make([]int, 0)
+	for i := from; i <= to; i++ {
+		out = append(out, i)
+	}
+	return out
+}
@@ -17,6 +17,15 @@ type (
 		Total  int   `json:"total"`
 		Users  Users `json:"users"`
 	}
+
+	// AgeBucket counts the users whose age falls between From and To, both inclusive.
+	AgeBucket struct {
+		From  int `json:"from"`
+		To    int `json:"to"`
+		Total int `json:"total"`
+	}
+
+	AgeBuckets []AgeBucket
 )
 
 func (u *User) Cols(fields []db.Column) []interface{} {
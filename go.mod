@@ -1,3 +1,5 @@
+// The tests against an embedded MySQL compatible engine live in the integration module, which
+// go test ./... does not reach from here. Run them with: cd integration && go test ./...
 module go-dao-pattern
 
 go 1.17
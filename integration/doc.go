@@ -0,0 +1,5 @@
+// Package integration tests the library against embedded MySQL compatible servers, with the
+// driver version the library ships. It is a module of its own, so run it from this directory:
+//
+//	go test ./...
+package integration
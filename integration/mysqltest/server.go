@@ -0,0 +1,107 @@
+package mysqltest
+
+import (
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"go-dao-pattern/pkg/storage/mysql"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+// Schema is the database every server starts with.
+const Schema = "company"
+
+// Server is an embedded MySQL compatible engine. It runs its setup statements every time it starts.
+type Server struct {
+	t       *testing.T
+	address *net.TCPAddr
+	setup   []string
+	server  *server.Server
+	admin   *sql.DB
+}
+
+// Start runs a server on a free local port and returns once it answers. The server stops with the test.
+func Start(t *testing.T, setup ...string) *Server {
+	// The server takes the listener over, so no one else can take the port in between.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	s := &Server{t: t, address: listener.Addr().(*net.TCPAddr), setup: setup}
+	s.serve(listener)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// Restart starts a stopped server again on the same address, with fresh data.
+func (s *Server) Restart() {
+	listener, err := net.Listen("tcp", s.address.String())
+	require.Nil(s.t, err)
+	s.serve(listener)
+}
+
+// Stop closes the server. Connections already open stay alive, as the engine does not track them.
+func (s *Server) Stop() {
+	if s.server == nil {
+		return
+	}
+
+	s.admin.Close()
+	s.server.Close()
+	s.server = nil
+}
+
+// Exec runs the statements as root.
+func (s *Server) Exec(statements ...string) {
+	for _, statement := range statements {
+		_, err := s.admin.Exec(statement)
+		require.Nil(s.t, err)
+	}
+}
+
+// Options returns the options to connect to the server.
+func (s *Server) Options() mysql.ConnectionOptions {
+	return mysql.ConnectionOptions{
+		Host:         s.address.IP.String(),
+		Port:         s.address.Port,
+		User:         "root",
+		Schema:       Schema,
+		RegisterName: "mysql",
+		ConnMaxOpen:  5,
+		ConnMaxIdle:  2,
+	}
+}
+
+// Replica returns the address of the server as a read replica.
+func (s *Server) Replica() mysql.Replica {
+	return mysql.Replica{Host: s.address.IP.String(), Port: s.address.Port}
+}
+
+func (s *Server) serve(listener net.Listener) {
+	database := memory.NewDatabase(Schema)
+	database.BaseDatabase.EnablePrimaryKeyIndexes()
+	provider := memory.NewDBProvider(database)
+
+	cfg := server.Config{Protocol: "tcp", Address: s.address.String(), Listener: listener}
+	srv, err := server.NewServer(cfg, sqle.NewDefault(provider), gms.NewContext, memory.NewSessionBuilder(provider), nil)
+	if err != nil {
+		listener.Close()
+	}
+	require.Nil(s.t, err)
+
+	s.server = srv
+	go srv.Start()
+
+	admin, err := sql.Open("mysql", "root:@tcp("+s.address.String()+")/"+Schema)
+	require.Nil(s.t, err)
+	s.admin = admin
+
+	require.Eventually(s.t, func() bool { return admin.Ping() == nil }, time.Second, 10*time.Millisecond)
+	s.Exec(s.setup...)
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-dao-pattern/integration/mysqltest"
+	"go-dao-pattern/pkg/storage/mysql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startNode runs a server whose node table holds its name.
+func startNode(t *testing.T, name string) *mysqltest.Server {
+	return mysqltest.Start(t, "CREATE TABLE node (name VARCHAR(32) NOT NULL)", "INSERT INTO node VALUES ('"+name+"')")
+}
+
+func queryNode(t *testing.T, c *mysql.StorageClient, ctx context.Context) string {
+	rows, err := c.Query(ctx, "SELECT name FROM node")
+	require.Nil(t, err)
+	defer rows.Close()
+
+	var name string
+	require.True(t, rows.Next())
+	require.Nil(t, rows.Scan(&name))
+	return name
+}
+
+func queryRowNode(t *testing.T, c *mysql.StorageClient, ctx context.Context) string {
+	var name string
+	require.Nil(t, c.QueryRow(ctx, "SELECT name FROM node").Scan(&name))
+	return name
+}
+
+func TestStorageClient_ReadWriteSplitting(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.Replicas = []mysql.Replica{startNode(t, "replica-1").Replica(), startNode(t, "replica-2").Replica()}
+
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	defer c.Close()
+	ctx := context.Background()
+
+	// Round robin alternates between the replicas.
+	assert.ElementsMatch(t, []string{"replica-1", "replica-2"}, []string{queryNode(t, c, ctx), queryNode(t, c, ctx)})
+	assert.ElementsMatch(t, []string{"replica-1", "replica-2"}, []string{queryRowNode(t, c, ctx), queryRowNode(t, c, ctx)})
+
+	// Writes go to the primary, where a forced read finds them.
+	_, err = c.Exec(ctx, "UPDATE node SET name = ?", "primary-updated")
+	require.Nil(t, err)
+	assert.Equal(t, "primary-updated", queryNode(t, c, mysql.WithPrimary(ctx)))
+	assert.Equal(t, "primary-updated", queryRowNode(t, c, mysql.WithPrimary(ctx)))
+	assert.NotEqual(t, "primary-updated", queryNode(t, c, ctx))
+}
+
+func TestStorageClient_LeastConnections(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.Replicas = []mysql.Replica{startNode(t, "replica-1").Replica(), startNode(t, "replica-2").Replica()}
+	opts.ReplicaBalance = mysql.LeastConnections
+
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	defer c.Close()
+	ctx := context.Background()
+
+	// Rows left open hold a connection on the first replica, which sends the reads to the second one.
+	rows, err := c.Query(ctx, "SELECT name FROM node")
+	require.Nil(t, err)
+	defer rows.Close()
+
+	var name string
+	require.True(t, rows.Next())
+	require.Nil(t, rows.Scan(&name))
+	assert.Equal(t, "replica-1", name)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "replica-2", queryRowNode(t, c, ctx))
+	}
+}
+
+func TestStorageClient_ReplicaEjection(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	replica := startNode(t, "replica")
+	opts.Replicas = []mysql.Replica{replica.Replica()}
+	opts.ReplicaCheckInterval = 20 * time.Millisecond
+
+	// Stopping a server leaves open connections alive, so none is kept idle, as after an outage.
+	opts.ConnMaxIdle = 0
+
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	defer c.Close()
+	ctx := context.Background()
+	assert.Equal(t, "replica", queryNode(t, c, ctx))
+
+	// Reads fall back to the primary while the replica is down.
+	replica.Stop()
+	assert.Equal(t, "primary", queryNode(t, c, ctx))
+	assert.Equal(t, "primary", queryRowNode(t, c, ctx))
+
+	// And return to it once it answers again.
+	replica.Restart()
+	assert.Eventually(t, func() bool {
+		return queryNode(t, c, ctx) == "replica"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStorageClient_ReplicaDownOnConnect(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	replica := startNode(t, "replica")
+	replica.Stop()
+	opts.Replicas = []mysql.Replica{replica.Replica()}
+
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	defer c.Close()
+
+	assert.Equal(t, "primary", queryRowNode(t, c, context.Background()))
+}
+
+func TestStorageClient_ReplicaCancelledRead(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.Replicas = []mysql.Replica{startNode(t, "replica").Replica()}
+
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	defer c.Close()
+
+	// A cancelled read is not retried on the primary, and the replica stays in rotation.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Query(ctx, "SELECT name FROM node")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, c.QueryRow(ctx, "SELECT name FROM node").Err(), context.Canceled)
+	assert.Equal(t, "replica", queryNode(t, c, context.Background()))
+}
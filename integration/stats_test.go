@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	oops "go-dao-pattern/pkg/errors"
+	"go-dao-pattern/pkg/storage/mysql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageClient_Ping(t *testing.T) {
+	n := startNode(t, "primary")
+	opts := n.Options()
+
+	// Stopping a server leaves open connections alive, so none is kept idle, as after an outage.
+	opts.ConnMaxIdle = 0
+	c := connect(t, opts)
+	assert.Nil(t, c.Ping(context.Background()))
+
+	n.Stop()
+	assert.True(t, oops.Is(oops.E5xxUNAVAILABLE, c.Ping(context.Background())))
+}
+
+func TestStorageClient_Stats(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	down := startNode(t, "replica-2")
+	down.Stop()
+	opts.Replicas = []mysql.Replica{startNode(t, "replica-1").Replica(), down.Replica()}
+	c := connect(t, opts)
+
+	// Rows left open hold a connection on the primary.
+	rows, err := c.Query(mysql.WithPrimary(context.Background()), "SELECT name FROM node")
+	require.Nil(t, err)
+	defer rows.Close()
+
+	stats := c.Stats()
+	assert.Equal(t, 1, stats.Primary.InUse)
+	require.Len(t, stats.Replicas, 2)
+	assert.Equal(t, net.JoinHostPort(opts.Replicas[0].Host, strconv.Itoa(opts.Replicas[0].Port)), stats.Replicas[0].Address)
+	assert.True(t, stats.Replicas[0].Healthy)
+	assert.False(t, stats.Replicas[1].Healthy)
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go-dao-pattern/pkg/storage/mysql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, opts mysql.ConnectionOptions) *mysql.StorageClient {
+	c, err := mysql.InitConnection(context.Background(), opts)
+	require.Nil(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestStorageClient_StmtCache(t *testing.T) {
+	c := connect(t, startNode(t, "primary").Options())
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := c.Exec(ctx, "UPDATE node SET name = ?", fmt.Sprint("primary-", i))
+		require.Nil(t, err)
+	}
+	assert.Equal(t, "primary-4", queryRowNode(t, c, ctx))
+	assert.Equal(t, "primary-4", queryRowNode(t, c, ctx))
+
+	assert.Equal(t, mysql.StmtCacheStats{Hits: 5, Misses: 2}, c.StmtCacheStats())
+}
+
+func TestStorageClient_StmtCacheEviction(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.StmtCacheSize = 1
+	c := connect(t, opts)
+	ctx := context.Background()
+
+	rows, err := c.Query(ctx, "SELECT name FROM node")
+	require.Nil(t, err)
+	defer rows.Close()
+
+	// Evicting the statement does not break the rows it is still producing.
+	_, err = c.Exec(ctx, "UPDATE node SET name = ?", "evicted")
+	require.Nil(t, err)
+	assert.Equal(t, mysql.StmtCacheStats{Misses: 2, Evictions: 1}, c.StmtCacheStats())
+
+	var name string
+	require.True(t, rows.Next())
+	require.Nil(t, rows.Scan(&name))
+	assert.Nil(t, rows.Err())
+}
+
+func TestStorageClient_StmtCacheConcurrentAccess(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.StmtCacheSize = 3
+	c := connect(t, opts)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				var n int
+				query := fmt.Sprintf("SELECT %d FROM node", (w+i)%6)
+				assert.Nil(t, c.QueryRow(ctx, query).Scan(&n))
+				assert.Equal(t, (w+i)%6, n)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := c.StmtCacheStats()
+	assert.Equal(t, uint64(160), stats.Hits+stats.Misses)
+	assert.NotZero(t, stats.Evictions)
+}
+
+func TestStorageClient_StmtCacheDisabled(t *testing.T) {
+	opts := startNode(t, "primary").Options()
+	opts.StmtCacheSize = -1
+	c := connect(t, opts)
+	ctx := context.Background()
+
+	assert.Equal(t, "primary", queryNode(t, c, ctx))
+	assert.Equal(t, "primary", queryRowNode(t, c, ctx))
+	assert.Equal(t, mysql.StmtCacheStats{}, c.StmtCacheStats())
+}
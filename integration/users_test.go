@@ -0,0 +1,33 @@
+package integration
+
+import (
+	"testing"
+
+	"go-dao-pattern/dao/users"
+	"go-dao-pattern/dao/users/userstest"
+	"go-dao-pattern/integration/mysqltest"
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	createUsers = `CREATE TABLE users (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		age INT NOT NULL
+	)`
+	dropUsers = `DROP TABLE IF EXISTS users`
+)
+
+func TestUserStorage_Conformance(t *testing.T) {
+	server := mysqltest.Start(t)
+
+	storage, err := users.NewUserStorage(context.NewBackgroundContext(), server.Options())
+	require.Nil(t, err)
+
+	userstest.Run(t, func(t *testing.T) users.DataAccess {
+		server.Exec(dropUsers, createUsers)
+		return storage
+	})
+}
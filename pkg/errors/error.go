@@ -13,6 +13,7 @@ const (
 	E4xxCLIENTSIDE    = "client_side"
 	E4xxUNAUTHORIZED  = "unauthorized"
 	E4xxNOTFOUND      = "not_found"
+	E4xxCONFLICT      = "conflict"
 	E4xxUNPROCESSABLE = "unprocessable_entity"
 )
 
@@ -22,6 +23,7 @@ var (
 		E5xxUNAVAILABLE:   http.StatusServiceUnavailable,
 		E4xxUNPROCESSABLE: http.StatusUnprocessableEntity,
 		E4xxNOTFOUND:      http.StatusNotFound,
+		E4xxCONFLICT:      http.StatusConflict,
 		E4xxCLIENTSIDE:    http.StatusBadRequest,
 		E4xxUNAUTHORIZED:  http.StatusUnauthorized,
 	}
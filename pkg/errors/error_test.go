@@ -13,6 +13,7 @@ func TestConstants(t *testing.T) {
 	assert.Equal(t, "client_side", E4xxCLIENTSIDE)
 	assert.Equal(t, "unauthorized", E4xxUNAUTHORIZED)
 	assert.Equal(t, "unprocessable_entity", E4xxUNPROCESSABLE)
+	assert.Equal(t, "conflict", E4xxCONFLICT)
 	assert.Equal(t, "internal", E5xxINTERNAL)
 }
 
@@ -53,6 +54,7 @@ func TestErrorStatus(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, ErrorStatus(Errorf(E5xxINTERNAL, "error error error")))
 	assert.Equal(t, http.StatusUnprocessableEntity, ErrorStatus(Errorf(E4xxUNPROCESSABLE, "error error error")))
 	assert.Equal(t, http.StatusNotFound, ErrorStatus(Errorf(E4xxNOTFOUND, "error error error")))
+	assert.Equal(t, http.StatusConflict, ErrorStatus(Errorf(E4xxCONFLICT, "error error error")))
 	assert.Equal(t, http.StatusUnauthorized, ErrorStatus(Errorf(E4xxUNAUTHORIZED, "error error error")))
 	assert.Equal(t, http.StatusBadRequest, ErrorStatus(Errorf(E4xxCLIENTSIDE, "error error error")))
 }
@@ -66,11 +66,25 @@ func NewCollector(env string) statsd.ClientInterface {
 }
 
 func IncrementCounter(metricName string, value int64, tags ...string) {
+	if instance == nil {
+		return
+	}
+
 	if err := instance.Count(metricName, value, tags, 1); err != nil {
 		log.Error("[IncrementCounter] fail sending metrics", err)
 	}
 }
 
+func Gauge(metricName string, value float64, tags ...string) {
+	if instance == nil {
+		return
+	}
+
+	if err := instance.Gauge(metricName, value, tags, 1); err != nil {
+		log.Error("[Gauge] fail sending metrics", err)
+	}
+}
+
 func StartSegment(f func(), opts ...SegmentOption) ddtrace.Span {
 	s := new(segment)
 	for _, opt := range opts {
@@ -97,8 +111,13 @@ func StartStoreSegment(f func() error, opts ...SegmentOption) {
 		opt(s)
 	}
 
+	spanType := ext.AppTypeDB
+	if Cache == s.platform {
+		spanType = ext.AppTypeCache
+	}
+
 	span, _ := tracer.StartSpanFromContext(s.ctx, s.action, tracer.ResourceName(s.resource),
-		tracer.Measured(), tracer.SpanType(ext.AppTypeDB), tracer.ChildOf(s.parent))
+		tracer.Measured(), tracer.SpanType(spanType), tracer.ChildOf(s.parent))
 
 	span.Finish(tracer.WithError(f()))
 }
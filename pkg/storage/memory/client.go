@@ -1,7 +1,7 @@
 package memory
 
 import (
-	"context"
+	"go-dao-pattern/pkg/context"
 )
 
 type (
@@ -10,5 +10,12 @@ type (
 	Client interface {
 		Get(ctx *context.Context, key string) (interface{}, error)
 		Save(ctx *context.Context, key string, value interface{}) error
+		Delete(ctx *context.Context, key string) error
+		Exists(ctx *context.Context, key string) bool
+		GetMany(ctx *context.Context, keys ...string) (map[string]interface{}, error)
+		SaveMany(ctx *context.Context, values map[string]interface{}) error
+		Scan(ctx *context.Context, prefix string, fn func(key string, value interface{}) bool)
+		Keys(ctx *context.Context, prefix string) []string
+		Len(ctx *context.Context) int
 	}
 )
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+var (
+	CodecUnknownTypeErr = errors.New("memory codec type is not registered")
+)
+
+type (
+	// Codec turns stored values into bytes for snapshots and the append log, and back.
+	Codec interface {
+		Marshal(value interface{}) ([]byte, error)
+		Unmarshal(data []byte) (interface{}, error)
+	}
+
+	// GobCodec encodes values with encoding/gob. Concrete types must be registered with gob.Register.
+	GobCodec struct{}
+
+	// JSONCodec encodes values as JSON tagged with their type name, so they decode back to the same type.
+	JSONCodec struct {
+		types map[string]reflect.Type
+	}
+
+	gobEnvelope struct {
+		Value interface{}
+	}
+
+	jsonEnvelope struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+)
+
+func (GobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobEnvelope{Value: value}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var e gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+// NewJSONCodec returns a JSONCodec able to decode values of the same types as the given samples.
+func NewJSONCodec(samples ...interface{}) *JSONCodec {
+	c := &JSONCodec{types: make(map[string]reflect.Type)}
+	for _, s := range samples {
+		t := reflect.TypeOf(s)
+		c.types[typeName(t)] = t
+	}
+	return c
+}
+
+func (c *JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	name := typeName(reflect.TypeOf(value))
+	if _, found := c.types[name]; !found {
+		return nil, CodecUnknownTypeErr
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonEnvelope{Type: name, Value: raw})
+}
+
+func (c *JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	var e jsonEnvelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	t, found := c.types[e.Type]
+	if !found {
+		return nil, CodecUnknownTypeErr
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(e.Value, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
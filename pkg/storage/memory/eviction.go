@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"container/heap"
+	"container/list"
+	"sort"
+	"time"
+)
+
+const (
+	NoEviction EvictionPolicy = iota
+	LRU
+	LFU
+)
+
+type (
+	EvictionPolicy int
+
+	item struct {
+		key     string
+		value   interface{}
+		expires time.Time
+		freq    int
+		touched int64
+		index   int
+		elem    *list.Element
+	}
+
+	// policy decides which item leaves a full shard. Callers hold the shard write lock.
+	policy interface {
+		add(it *item)
+		touch(it *item)
+		remove(it *item)
+		victim() *item
+		// victims returns up to n items in eviction order, leaving out the skipped ones, without removing them.
+		victims(n int, skip func(it *item) bool) []*item
+	}
+
+	noPolicy struct{}
+
+	lruPolicy struct {
+		l *list.List
+	}
+
+	lfuPolicy struct {
+		h    lfuHeap
+		tick int64
+	}
+
+	lfuHeap []*item
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case LRU:
+		return "lru"
+	case LFU:
+		return "lfu"
+	default:
+		return "none"
+	}
+}
+
+func newPolicy(p EvictionPolicy) policy {
+	switch p {
+	case LRU:
+		return &lruPolicy{l: list.New()}
+	case LFU:
+		return &lfuPolicy{h: make(lfuHeap, 0)}
+	default:
+		return noPolicy{}
+	}
+}
+
+func (it *item) expired(now time.Time) bool {
+	return !it.expires.IsZero() && !now.Before(it.expires)
+}
+
+func (noPolicy) add(*item)     {}
+func (noPolicy) touch(*item)   {}
+func (noPolicy) remove(*item)  {}
+func (noPolicy) victim() *item { return nil }
+
+func (noPolicy) victims(int, func(*item) bool) []*item { return nil }
+
+func (p *lruPolicy) add(it *item) {
+	it.elem = p.l.PushFront(it)
+}
+
+func (p *lruPolicy) touch(it *item) {
+	p.l.MoveToFront(it.elem)
+}
+
+func (p *lruPolicy) remove(it *item) {
+	p.l.Remove(it.elem)
+	it.elem = nil
+}
+
+func (p *lruPolicy) victim() *item {
+	if back := p.l.Back(); back != nil {
+		return back.Value.(*item)
+	}
+	return nil
+}
+
+func (p *lruPolicy) victims(n int, skip func(it *item) bool) []*item {
+	items := make([]*item, 0, n)
+	for e := p.l.Back(); e != nil && len(items) < n; e = e.Prev() {
+		if it := e.Value.(*item); !skip(it) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+func (p *lfuPolicy) add(it *item) {
+	p.tick++
+	it.freq = 1
+	it.touched = p.tick
+	heap.Push(&p.h, it)
+}
+
+func (p *lfuPolicy) touch(it *item) {
+	p.tick++
+	it.freq++
+	it.touched = p.tick
+	heap.Fix(&p.h, it.index)
+}
+
+func (p *lfuPolicy) remove(it *item) {
+	heap.Remove(&p.h, it.index)
+}
+
+func (p *lfuPolicy) victim() *item {
+	if len(p.h) > 0 {
+		return p.h[0]
+	}
+	return nil
+}
+
+func (p *lfuPolicy) victims(n int, skip func(it *item) bool) []*item {
+	ordered := make(lfuHeap, 0, len(p.h))
+	for _, it := range p.h {
+		if !skip(it) {
+			ordered = append(ordered, it)
+		}
+	}
+
+	// Sorting a copy keeps the positions the heap tracks on its items.
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered.Less(i, j)
+	})
+
+	if len(ordered) > n {
+		ordered = ordered[:n]
+	}
+	return ordered
+}
+
+// lfuHeap orders items by access count, breaking ties with the least recently touched.
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].touched < h[j].touched
+	}
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*h = old[:n-1]
+	return it
+}
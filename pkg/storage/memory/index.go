@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"errors"
+	"go-dao-pattern/pkg/context"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	IndexNotFoundErr  = errors.New("memory index not found")
+	IndexExistsErr    = errors.New("memory index already exists")
+	IndexValueTypeErr = errors.New("memory index values should be numbers or strings")
+)
+
+type (
+	// IndexFunc extracts the indexed value of an entry. Returning false leaves the entry out of the index.
+	// It must be deterministic, since it is called again to unindex the entry when it is replaced or removed.
+	IndexFunc func(key string, value interface{}) (interface{}, bool)
+
+	// Bound limits one side of a range lookup.
+	Bound struct {
+		Value     interface{}
+		Inclusive bool
+	}
+
+	// Range selects indexed values between From and To. A nil bound leaves that side open.
+	Range struct {
+		From *Bound
+		To   *Bound
+	}
+
+	indexes struct {
+		mu     sync.RWMutex
+		byName map[string]*index
+	}
+
+	index struct {
+		mu     sync.RWMutex
+		prefix string
+		fn     IndexFunc
+		list   *skiplist
+	}
+)
+
+func newIndexes() *indexes {
+	return &indexes{byName: make(map[string]*index)}
+}
+
+// CreateIndex declares a secondary index over the entries stored under prefix. Numbers are ordered
+// numerically and strings lexically. Entries already stored are indexed before it returns.
+func (s *StorageClient) CreateIndex(name, prefix string, fn IndexFunc) error {
+	idx := &index{prefix: prefix, fn: fn, list: newSkiplist()}
+
+	s.indexes.mu.Lock()
+	if _, found := s.indexes.byName[name]; found {
+		s.indexes.mu.Unlock()
+		return IndexExistsErr
+	}
+	s.indexes.byName[name] = idx
+	s.indexes.mu.Unlock()
+
+	// Writes racing with the backfill are indexed by set, and inserting twice is harmless.
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for _, it := range sh.items {
+			idx.add(it)
+		}
+		sh.mu.RUnlock()
+	}
+	return nil
+}
+
+// Lookup returns the live values whose indexed value equals value.
+func (s *StorageClient) Lookup(ctx *context.Context, name string, value interface{}) ([]interface{}, error) {
+	values := make([]interface{}, 0)
+	err := s.Range(ctx, name, Range{
+		From: &Bound{Value: value, Inclusive: true},
+		To:   &Bound{Value: value, Inclusive: true},
+	}, func(_ string, v interface{}) bool {
+		values = append(values, v)
+		return true
+	})
+	return values, err
+}
+
+// Range calls fn in index order for every live entry within r until fn returns false.
+// Entries are collected before fn runs, so fn may safely call back into the client.
+func (s *StorageClient) Range(ctx *context.Context, name string, r Range, fn func(key string, value interface{}) bool) error {
+	var err error
+	s.trace(ctx, rangeAction, func() error {
+		err = s.scanRange(name, r, fn)
+		return err
+	})
+	return err
+}
+
+func (s *StorageClient) scanRange(name string, r Range, fn func(key string, value interface{}) bool) error {
+	s.indexes.mu.RLock()
+	idx, found := s.indexes.byName[name]
+	s.indexes.mu.RUnlock()
+
+	if !found {
+		return IndexNotFoundErr
+	}
+
+	from, to, err := r.normalize()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range idx.collect(from, to, s.now()) {
+		if !fn(e.key, e.value) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// collect returns the live entries within the bounds in index order. Callbacks must not run under the
+// index lock: a writer holding a shard lock waits for it, and a callback reading that shard would deadlock.
+func (idx *index) collect(from, to *Bound, now time.Time) []entry {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	n := idx.list.first()
+	if from != nil {
+		n = idx.list.seek(from.Value, !from.Inclusive)
+	}
+
+	entries := make([]entry, 0)
+	for ; n != nil; n = n.next[0] {
+		if to != nil {
+			c := compareValues(n.value, to.Value)
+			if c > 0 || (c == 0 && !to.Inclusive) {
+				break
+			}
+		}
+
+		if !n.it.expired(now) {
+			entries = append(entries, entry{key: n.it.key, value: n.it.value})
+		}
+	}
+	return entries
+}
+
+func (r Range) normalize() (*Bound, *Bound, error) {
+	bounds := []*Bound{r.From, r.To}
+	for i, b := range bounds {
+		if b == nil {
+			continue
+		}
+
+		v, ok := normalize(b.Value)
+		if !ok {
+			return nil, nil, IndexValueTypeErr
+		}
+		bounds[i] = &Bound{Value: v, Inclusive: b.Inclusive}
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// add indexes the item on every index whose prefix matches. Callers hold the shard lock.
+func (ix *indexes) add(it *item) {
+	ix.each(it.key, func(idx *index) {
+		idx.add(it)
+	})
+}
+
+// remove unindexes the item from every index whose prefix matches. Callers hold the shard lock.
+func (ix *indexes) remove(it *item) {
+	ix.each(it.key, func(idx *index) {
+		idx.remove(it)
+	})
+}
+
+func (ix *indexes) each(key string, fn func(idx *index)) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	for _, idx := range ix.byName {
+		if strings.HasPrefix(key, idx.prefix) {
+			fn(idx)
+		}
+	}
+}
+
+func (idx *index) add(it *item) {
+	v, ok := idx.value(it)
+	if !ok {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.list.insert(v, it)
+}
+
+func (idx *index) remove(it *item) {
+	v, ok := idx.value(it)
+	if !ok {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.list.delete(v, it.key)
+}
+
+func (idx *index) value(it *item) (interface{}, bool) {
+	if !strings.HasPrefix(it.key, idx.prefix) {
+		return nil, false
+	}
+
+	v, ok := idx.fn(it.key, it.value)
+	if !ok {
+		return nil, false
+	}
+	return normalize(v)
+}
+
+// normalize widens numbers to float64 so that every numeric kind shares one ordering.
+func normalize(v interface{}) (interface{}, bool) {
+	switch n := v.(type) {
+	case string:
+		return n, true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return nil, false
+	}
+}
+
+// compareValues orders normalized values. Numbers sort before strings.
+func compareValues(a, b interface{}) int {
+	af, aNum := a.(float64)
+	bf, bNum := b.(float64)
+
+	switch {
+	case aNum && bNum:
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
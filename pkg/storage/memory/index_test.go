@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type person struct {
+	Name string
+	Age  int
+}
+
+func ageIndex(_ string, v interface{}) (interface{}, bool) {
+	p, ok := v.(person)
+	return p.Age, ok
+}
+
+func nameIndex(_ string, v interface{}) (interface{}, bool) {
+	p, ok := v.(person)
+	return p.Name, ok
+}
+
+func keysInRange(t *testing.T, s *StorageClient, name string, r Range) []string {
+	keys := make([]string, 0)
+	err := s.Range(context.NewBackgroundContext(), name, r, func(key string, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Nil(t, err)
+	return keys
+}
+
+func TestStorageClient_IndexLookup(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	assert.Nil(t, s.Save(ctx, "people:1", person{Name: "ana", Age: 30}))
+	assert.Nil(t, s.Save(ctx, "people:2", person{Name: "bob", Age: 40}))
+	assert.Nil(t, s.Save(ctx, "people:3", person{Name: "eve", Age: 30}))
+	assert.Nil(t, s.Save(ctx, "pets:1", person{Name: "rex", Age: 30}))
+
+	values, err := s.Lookup(ctx, "age", 30)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []interface{}{person{Name: "ana", Age: 30}, person{Name: "eve", Age: 30}}, values)
+
+	values, err = s.Lookup(ctx, "age", int64(40))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{person{Name: "bob", Age: 40}}, values)
+
+	values, err = s.Lookup(ctx, "age", 50)
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+}
+
+func TestStorageClient_IndexRange(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	for i := 1; i <= 5; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("people:%d", i), person{Age: i * 10}))
+	}
+
+	cases := []struct {
+		name     string
+		r        Range
+		expected []string
+	}{
+		{"All", Range{}, []string{"people:1", "people:2", "people:3", "people:4", "people:5"}},
+		{"GreaterThan", Range{From: &Bound{Value: 30}}, []string{"people:4", "people:5"}},
+		{"GreaterEqualsThan", Range{From: &Bound{Value: 30, Inclusive: true}}, []string{"people:3", "people:4", "people:5"}},
+		{"LessThan", Range{To: &Bound{Value: 30}}, []string{"people:1", "people:2"}},
+		{"LessEqualsThan", Range{To: &Bound{Value: 30, Inclusive: true}}, []string{"people:1", "people:2", "people:3"}},
+		{"Between", Range{From: &Bound{Value: 15}, To: &Bound{Value: 45.5}}, []string{"people:2", "people:3", "people:4"}},
+		{"Empty", Range{From: &Bound{Value: 30}, To: &Bound{Value: 30}}, []string{}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, keysInRange(t, s, "age", c.r))
+		})
+	}
+}
+
+func TestStorageClient_IndexStrings(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("name", "people:", nameIndex))
+
+	assert.Nil(t, s.Save(ctx, "people:1", person{Name: "carla"}))
+	assert.Nil(t, s.Save(ctx, "people:2", person{Name: "ana"}))
+	assert.Nil(t, s.Save(ctx, "people:3", person{Name: "bruno"}))
+
+	assert.Equal(t, []string{"people:2", "people:3"}, keysInRange(t, s, "name", Range{To: &Bound{Value: "c"}}))
+	assert.Equal(t, []string{"people:3", "people:1"}, keysInRange(t, s, "name", Range{From: &Bound{Value: "ana"}}))
+}
+
+func TestStorageClient_IndexConsistency(t *testing.T) {
+	now := time.Now()
+	s := InitConnection()
+	s.now = func() time.Time { return now }
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	assert.Nil(t, s.Save(ctx, "people:1", person{Age: 30}))
+	assert.Nil(t, s.Save(ctx, "people:2", person{Age: 30}))
+	assert.Nil(t, s.SaveWithTTL(ctx, "people:3", person{Age: 30}, time.Second))
+
+	// Replacing a value moves it in the index.
+	assert.Nil(t, s.Save(ctx, "people:1", person{Age: 40}))
+	assert.Equal(t, []string{"people:2", "people:3"}, keysInRange(t, s, "age", Range{To: &Bound{Value: 30, Inclusive: true}}))
+	assert.Equal(t, []string{"people:1"}, keysInRange(t, s, "age", Range{From: &Bound{Value: 30}}))
+
+	assert.Nil(t, s.Delete(ctx, "people:2"))
+	assert.Equal(t, []string{"people:3"}, keysInRange(t, s, "age", Range{To: &Bound{Value: 30, Inclusive: true}}))
+
+	// Expired entries are hidden at once and unindexed when purged.
+	now = now.Add(time.Minute)
+	assert.Empty(t, keysInRange(t, s, "age", Range{To: &Bound{Value: 30, Inclusive: true}}))
+	s.Purge()
+	assert.Equal(t, 1, s.indexes.byName["age"].list.len)
+}
+
+func TestStorageClient_IndexEviction(t *testing.T) {
+	s := InitConnection(WithMaxEntries(10, LRU))
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("people:%d", i), person{Age: i}))
+	}
+
+	assert.ElementsMatch(t, s.Keys(ctx, "people:"), keysInRange(t, s, "age", Range{}))
+}
+
+func TestStorageClient_IndexBackfill(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "people:1", person{Age: 10}))
+	assert.Nil(t, s.Save(ctx, "people:2", person{Age: 20}))
+	assert.Nil(t, s.Save(ctx, "people:3", "not a person"))
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	assert.Equal(t, []string{"people:1", "people:2"}, keysInRange(t, s, "age", Range{}))
+}
+
+func TestStorageClient_IndexErrors(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+	assert.Equal(t, IndexExistsErr, s.CreateIndex("age", "people:", ageIndex))
+
+	_, err := s.Lookup(ctx, "name", "ana")
+	assert.Equal(t, IndexNotFoundErr, err)
+
+	_, err = s.Lookup(ctx, "age", []int{1})
+	assert.Equal(t, IndexValueTypeErr, err)
+}
+
+func TestStorageClient_IndexConcurrentAccess(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("people:%d", i)
+				switch (w + i) % 3 {
+				case 0:
+					_ = s.Save(ctx, key, person{Age: w * i})
+				case 1:
+					_ = s.Delete(ctx, key)
+				default:
+					_, _ = s.Lookup(ctx, "age", i)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, s.Keys(ctx, "people:"), keysInRange(t, s, "age", Range{}))
+}
+
+// A callback reading the client must not deadlock with a writer waiting for the index.
+func TestStorageClient_IndexRangeCallbackReads(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("people:%d", i), person{Age: i}))
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = s.Save(ctx, fmt.Sprintf("people:%d", i%100), person{Age: i % 100})
+				}
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			_ = s.Range(ctx, "age", Range{}, func(key string, _ interface{}) bool {
+				_, _ = s.Get(ctx, key)
+				return true
+			})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("range callback deadlocked with a concurrent writer")
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestSkiplist_Order(t *testing.T) {
+	l := newSkiplist()
+	expected := make([]float64, 0)
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 5000; i++ {
+		v := float64(rnd.Intn(1000))
+		l.insert(v, &item{key: fmt.Sprint(i)})
+		expected = append(expected, v)
+	}
+
+	for i := 0; i < 5000; i += 2 {
+		l.delete(expected[i], fmt.Sprint(i))
+	}
+
+	kept := make([]float64, 0)
+	for i := 1; i < 5000; i += 2 {
+		kept = append(kept, expected[i])
+	}
+	sort.Float64s(kept)
+
+	got := make([]float64, 0)
+	for n := l.first(); n != nil; n = n.next[0] {
+		got = append(got, n.value.(float64))
+	}
+
+	assert.Equal(t, kept, got)
+	assert.Equal(t, len(kept), l.len)
+}
+
+func BenchmarkStorageClient_IndexRange(b *testing.B) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	_ = s.CreateIndex("age", "people:", ageIndex)
+	for i := 0; i < 300000; i++ {
+		_ = s.Save(ctx, fmt.Sprintf("people:%d", i), person{Age: i % 100})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.Range(ctx, "age", Range{From: &Bound{Value: 98}}, func(string, interface{}) bool {
+			return true
+		})
+	}
+}
@@ -1,33 +1,611 @@
 package memory
 
 import (
+	stdcontext "context"
 	"errors"
 	"go-dao-pattern/pkg/context"
+	"go-dao-pattern/pkg/metrics"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+const (
+	shardCount = 32
+
+	hitsMetric        = "memory.storage.hits"
+	missesMetric      = "memory.storage.misses"
+	evictionsMetric   = "memory.storage.evictions"
+	expirationsMetric = "memory.storage.expirations"
+
+	getAction     = "GET"
+	setAction     = "SET"
+	deleteAction  = "DELETE"
+	existsAction  = "EXISTS"
+	getManyAction = "MGET"
+	setManyAction = "MSET"
+	scanAction    = "SCAN"
+	lenAction     = "LEN"
+	rangeAction   = "RANGE"
+	commitAction  = "COMMIT"
 )
 
 var (
 	DataNotFoundErr = errors.New("memory data not found")
 )
 
-type StorageClient struct {
-	m Memory
-}
+// Ensure type implements interface.
+var _ Client = (*StorageClient)(nil)
+
+type (
+	// StorageClient is safe for concurrent use. Keys are spread over shards,
+	// each guarded by its own read/write lock, so readers never contend with
+	// writers of unrelated keys.
+	StorageClient struct {
+		shards       []*shard
+		indexes      *indexes
+		watchers     *watchers
+		journal      *journal
+		snapshotting sync.Mutex
+		opts         options
+		tags         []string
+		stats        Stats
+		now          func() time.Time
+		done         chan struct{}
+		closer       sync.Once
+		closeErr     error
+	}
+
+	// Stats counts cache events since the client was created.
+	Stats struct {
+		Hits        uint64
+		Misses      uint64
+		Evictions   uint64
+		Expirations uint64
+	}
+
+	shard struct {
+		mu       sync.RWMutex
+		items    map[string]*item
+		capacity int
+		policy   policy
+		indexes  *indexes
+		watchers *watchers
+		journal  *journal
+	}
+
+	entry struct {
+		key   string
+		value interface{}
+	}
+)
 
 func (s *StorageClient) Get(ctx *context.Context, key string) (interface{}, error) {
-	data, found := s.m[key]
+	var (
+		data interface{}
+		err  error
+	)
+
+	s.trace(ctx, getAction, func() error {
+		data, err = s.get(key)
+		return ignoreMiss(err)
+	})
+	return data, err
+}
+
+// Save stores the value using the default TTL of the client.
+func (s *StorageClient) Save(ctx *context.Context, key string, value interface{}) error {
+	return s.SaveWithTTL(ctx, key, value, s.opts.defaultTTL)
+}
+
+// SaveWithTTL stores the value until ttl elapses. Zero keeps it until it is deleted or evicted.
+func (s *StorageClient) SaveWithTTL(ctx *context.Context, key string, value interface{}, ttl time.Duration) error {
+	var err error
+	s.trace(ctx, setAction, func() error {
+		err = s.set(key, value, ttl)
+		return err
+	})
+	return err
+}
+
+func (s *StorageClient) Delete(ctx *context.Context, key string) error {
+	var err error
+	s.trace(ctx, deleteAction, func() error {
+		found, e := s.shard(key).remove(key, s.now())
+		if e == nil && !found {
+			e = DataNotFoundErr
+		}
+
+		err = e
+		return ignoreMiss(err)
+	})
+	return err
+}
+
+// Exists reports whether a live entry is stored under key. It does not count as a hit or a miss.
+func (s *StorageClient) Exists(ctx *context.Context, key string) bool {
+	var found bool
+	s.trace(ctx, existsAction, func() error {
+		found = s.shard(key).exists(key, s.now())
+		return nil
+	})
+	return found
+}
+
+// GetMany returns the live values for the given keys. Missing keys are left out of the result.
+func (s *StorageClient) GetMany(ctx *context.Context, keys ...string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(keys))
+	s.trace(ctx, getManyAction, func() error {
+		for _, key := range keys {
+			if data, err := s.get(key); err == nil {
+				values[key] = data
+			}
+		}
+		return nil
+	})
+	return values, nil
+}
+
+// SaveMany stores every value using the default TTL of the client.
+func (s *StorageClient) SaveMany(ctx *context.Context, values map[string]interface{}) error {
+	var err error
+	s.trace(ctx, setManyAction, func() error {
+		for key, value := range values {
+			if err = s.set(key, value, s.opts.defaultTTL); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// Keys returns the keys starting with prefix, in no particular order.
+func (s *StorageClient) Keys(ctx *context.Context, prefix string) []string {
+	keys := make([]string, 0)
+	s.Scan(ctx, prefix, func(key string, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Len returns the number of live entries.
+func (s *StorageClient) Len(ctx *context.Context) int {
+	n := 0
+	s.trace(ctx, lenAction, func() error {
+		now := s.now()
+		for _, sh := range s.shards {
+			n += sh.len(now)
+		}
+		return nil
+	})
+	return n
+}
+
+// Scan calls fn for every entry whose key starts with prefix until fn returns false.
+// Entries are collected shard by shard before fn runs, so fn may safely call back into the client.
+func (s *StorageClient) Scan(ctx *context.Context, prefix string, fn func(key string, value interface{}) bool) {
+	s.trace(ctx, scanAction, func() error {
+		now := s.now()
+		for _, sh := range s.shards {
+			for _, e := range sh.collect(prefix, now) {
+				if !fn(e.key, e.value) {
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StorageClient) get(key string) (interface{}, error) {
+	data, found, expired := s.shard(key).get(key, s.now())
+	switch {
+	case found:
+		s.count(&s.stats.Hits, hitsMetric, 1)
+		return data, nil
+	case expired:
+		s.count(&s.stats.Expirations, expirationsMetric, 1)
+	}
+
+	s.count(&s.stats.Misses, missesMetric, 1)
+	return nil, DataNotFoundErr
+}
+
+func (s *StorageClient) set(key string, value interface{}, ttl time.Duration) error {
+	it := &item{key: key, value: value}
+	if ttl > 0 {
+		it.expires = s.now().Add(ttl)
+	}
+
+	evicted, err := s.shard(key).set(it)
+	if evicted > 0 {
+		s.count(&s.stats.Evictions, evictionsMetric, evicted)
+	}
+	return err
+}
+
+// Stats returns a snapshot of the cache counters.
+func (s *StorageClient) Stats() Stats {
+	return Stats{
+		Hits:        atomic.LoadUint64(&s.stats.Hits),
+		Misses:      atomic.LoadUint64(&s.stats.Misses),
+		Evictions:   atomic.LoadUint64(&s.stats.Evictions),
+		Expirations: atomic.LoadUint64(&s.stats.Expirations),
+	}
+}
+
+// Purge drops every expired entry. The janitor calls it periodically.
+func (s *StorageClient) Purge() {
+	now := s.now()
+	purged := 0
+	for _, sh := range s.shards {
+		purged += sh.purge(now)
+	}
+
+	if purged > 0 {
+		s.count(&s.stats.Expirations, expirationsMetric, purged)
+	}
+}
+
+// Close stops the background goroutines, takes a last snapshot and closes the append log.
+// The stored data remains readable, but writes are no longer persisted.
+func (s *StorageClient) Close() error {
+	s.closer.Do(func() {
+		close(s.done)
+
+		if len(s.opts.snapshot) > 0 {
+			s.closeErr = s.Snapshot()
+		}
+
+		if err := s.journal.close(); err != nil && s.closeErr == nil {
+			s.closeErr = err
+		}
+
+		for _, sh := range s.shards {
+			sh.mu.Lock()
+			sh.journal = nil
+			sh.mu.Unlock()
+		}
+	})
+	return s.closeErr
+}
+
+func (s *StorageClient) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Purge()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// trace reports the operation as a cache span of the store, so memory calls show up in traces like MySQL ones.
+func (s *StorageClient) trace(ctx *context.Context, action string, f func() error) {
+	c, span := parent(ctx)
+	metrics.StartStoreSegment(f,
+		metrics.WithAction(action),
+		metrics.WithResource(s.opts.name),
+		metrics.WithPlatform(metrics.Cache),
+		metrics.WithParent(c, span, nil))
+}
+
+// parent returns the standard context and the span of ctx. A nil ctx stands for
+// context.Background() with no parent span, as the client has always accepted it.
+func parent(ctx *context.Context) (stdcontext.Context, ddtrace.SpanContext) {
+	if ctx == nil {
+		return stdcontext.Background(), nil
+	}
+
+	c := ctx.Context()
+	if c == nil {
+		c = stdcontext.Background()
+	}
+	return c, ctx.ParentSpam()
+}
+
+// ignoreMiss keeps misses from being reported as failed operations.
+func ignoreMiss(err error) error {
+	if err == DataNotFoundErr {
+		return nil
+	}
+	return err
+}
+
+func (s *StorageClient) count(counter *uint64, metric string, n int) {
+	atomic.AddUint64(counter, uint64(n))
+	metrics.IncrementCounter(metric, int64(n), s.tags...)
+}
+
+func (s *StorageClient) shard(key string) *shard {
+	return s.shards[s.shardIndex(key)]
+}
+
+func (s *StorageClient) shardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(s.shards)))
+}
+
+// get returns the live value for key. Expired entries are dropped and reported as such.
+func (sh *shard) get(key string, now time.Time) (interface{}, bool, bool) {
+	// Eviction policies reorder on every read, which needs the write lock.
+	if _, unordered := sh.policy.(noPolicy); unordered {
+		sh.mu.RLock()
+		it, found := sh.items[key]
+		sh.mu.RUnlock()
+
+		if !found {
+			return nil, false, false
+		}
+
+		if !it.expired(now) {
+			return it.value, true, false
+		}
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	it, found := sh.items[key]
 	if !found {
-		return nil, DataNotFoundErr
+		return nil, false, false
+	}
+
+	if it.expired(now) {
+		sh.drop(it)
+		return nil, false, true
 	}
-	return data, nil
+
+	sh.policy.touch(it)
+	return it.value, true, false
 }
 
-func (s *StorageClient) Save(ctx *context.Context, key string, value interface{}) error {
-	s.m[key] = value
-	return nil
+// set stores the item and returns how many entries were evicted to make room for it.
+// Nothing changes in memory unless the write was logged first.
+func (sh *shard) set(it *item) (int, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if err := sh.journal.put(it); err != nil {
+		return 0, err
+	}
+	return sh.place(it)
 }
 
-func InitConnection() *StorageClient {
-	client := new(StorageClient)
-	client.m = make(Memory)
+// place stores an item whose write is already logged, evicting to make room for it. Callers hold the write lock.
+func (sh *shard) place(it *item) (int, error) {
+	if old, found := sh.items[it.key]; found {
+		sh.unlink(old)
+	}
+
+	evicted := 0
+	for sh.capacity > 0 && len(sh.items) >= sh.capacity {
+		victim := sh.policy.victim()
+		if victim == nil {
+			break
+		}
+
+		if err := sh.journal.delete(victim.key); err != nil {
+			return evicted, err
+		}
+		sh.drop(victim)
+		evicted++
+	}
+
+	sh.insert(it)
+	return evicted, nil
+}
+
+// insert stores the item, replacing the previous one, without making room for it. Callers hold the write lock.
+func (sh *shard) insert(it *item) {
+	if old, found := sh.items[it.key]; found {
+		sh.unlink(old)
+	}
+
+	sh.items[it.key] = it
+	sh.policy.add(it)
+	sh.indexes.add(it)
+	sh.watchers.notify(Event{Type: PutEvent, Key: it.key, Value: it.value})
+}
+
+// peek returns the live item stored under key, without reordering the eviction policy.
+func (sh *shard) peek(key string, now time.Time) *item {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.current(key, now)
+}
+
+// current returns the live item stored under key. Callers hold the lock.
+func (sh *shard) current(key string, now time.Time) *item {
+	it, found := sh.items[key]
+	if !found || it.expired(now) {
+		return nil
+	}
+	return it
+}
+
+func (sh *shard) exists(key string, now time.Time) bool {
+	return sh.peek(key, now) != nil
+}
+
+func (sh *shard) len(now time.Time) int {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	n := 0
+	for _, it := range sh.items {
+		if !it.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+func (sh *shard) remove(key string, now time.Time) (bool, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	it, found := sh.items[key]
+	if !found {
+		return false, nil
+	}
+
+	if err := sh.journal.delete(key); err != nil {
+		return false, err
+	}
+
+	sh.drop(it)
+	return !it.expired(now), nil
+}
+
+func (sh *shard) purge(now time.Time) int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	purged := 0
+	for _, it := range sh.items {
+		if it.expired(now) {
+			sh.drop(it)
+			purged++
+		}
+	}
+	return purged
+}
+
+func (sh *shard) live(now time.Time) []*item {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	items := make([]*item, 0, len(sh.items))
+	for _, it := range sh.items {
+		if !it.expired(now) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+func (sh *shard) collect(prefix string, now time.Time) []entry {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	entries := make([]entry, 0)
+	for k, it := range sh.items {
+		if strings.HasPrefix(k, prefix) && !it.expired(now) {
+			entries = append(entries, entry{key: k, value: it.value})
+		}
+	}
+	return entries
+}
+
+// drop removes the item and notifies the watchers. Callers hold the write lock.
+func (sh *shard) drop(it *item) {
+	sh.unlink(it)
+	sh.watchers.notify(Event{Type: DeleteEvent, Key: it.key, Value: it.value})
+}
+
+// unlink removes the item without notifying, for items being replaced. Callers hold the write lock.
+func (sh *shard) unlink(it *item) {
+	delete(sh.items, it.key)
+	sh.policy.remove(it)
+	sh.indexes.remove(it)
+}
+
+// InitConnection creates a client and panics if its persisted state cannot be restored.
+func InitConnection(opts ...Option) *StorageClient {
+	client, err := NewStorageClient(opts...)
+	if err != nil {
+		panic(err)
+	}
 	return client
 }
+
+// NewStorageClient creates a client. When persistence is configured, it is rebuilt from the
+// snapshot and the append log before it is returned.
+func NewStorageClient(opts ...Option) (*StorageClient, error) {
+	o := options{name: defaultName, codec: GobCodec{}, watchBuffer: defaultWatchBuffer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.eviction == NoEviction {
+		o.maxEntries = 0
+	}
+
+	shards := shardCount
+	if o.maxEntries > 0 && o.maxEntries < shards {
+		shards = o.maxEntries
+	}
+
+	client := new(StorageClient)
+	client.opts = o
+	client.tags = []string{"store:" + o.name}
+	client.now = time.Now
+	client.done = make(chan struct{})
+	client.indexes = newIndexes()
+	client.watchers = newWatchers()
+	client.shards = make([]*shard, shards)
+	for i := range client.shards {
+		client.shards[i] = newShard(capacity(o.maxEntries, shards, i), o.eviction, client.indexes, client.watchers)
+	}
+
+	if err := client.restore(); err != nil {
+		return nil, err
+	}
+
+	if len(o.appendLog) > 0 {
+		j, err := openJournal(o.appendLog, o.codec)
+		if err != nil {
+			return nil, err
+		}
+
+		client.journal = j
+		for _, sh := range client.shards {
+			sh.journal = j
+		}
+	}
+
+	if o.janitor > 0 {
+		go client.janitor(o.janitor)
+	}
+
+	if len(o.snapshot) > 0 && o.snapshotInterval > 0 {
+		go client.snapshots(o.snapshotInterval)
+	}
+
+	return client, nil
+}
+
+func newShard(capacity int, eviction EvictionPolicy, ix *indexes, ws *watchers) *shard {
+	return &shard{
+		items:    make(map[string]*item),
+		capacity: capacity,
+		policy:   newPolicy(eviction),
+		indexes:  ix,
+		watchers: ws,
+	}
+}
+
+// capacity splits the entry bound across shards so that the shards add up to exactly max.
+func capacity(max, shards, i int) int {
+	if max <= 0 {
+		return 0
+	}
+
+	c := max / shards
+	if i < max%shards {
+		c++
+	}
+	return c
+}
@@ -0,0 +1,376 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestStorageClient_SaveAndGet(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "users:1", "leonardo"))
+
+	data, err := s.Get(ctx, "users:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "leonardo", data)
+
+	_, err = s.Get(ctx, "users:2")
+	assert.Equal(t, DataNotFoundErr, err)
+}
+
+func TestStorageClient_Delete(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "users:1", "leonardo"))
+	assert.Nil(t, s.Delete(ctx, "users:1"))
+	assert.Equal(t, DataNotFoundErr, s.Delete(ctx, "users:1"))
+
+	_, err := s.Get(ctx, "users:1")
+	assert.Equal(t, DataNotFoundErr, err)
+}
+
+func TestStorageClient_Scan(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("users:%d", i), i))
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("orders:%d", i), i))
+	}
+
+	seen := make(map[string]interface{})
+	s.Scan(ctx, "users:", func(key string, value interface{}) bool {
+		seen[key] = value
+		return true
+	})
+	assert.Len(t, seen, 100)
+	assert.Equal(t, 42, seen["users:42"])
+
+	visited := 0
+	s.Scan(ctx, "", func(key string, value interface{}) bool {
+		visited++
+		return visited < 10
+	})
+	assert.Equal(t, 10, visited)
+}
+
+func TestStorageClient_ScanCallbackWrites(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("users:%d", i), i))
+	}
+
+	s.Scan(ctx, "users:", func(key string, value interface{}) bool {
+		assert.Nil(t, s.Delete(ctx, key))
+		return true
+	})
+
+	s.Scan(ctx, "users:", func(key string, value interface{}) bool {
+		t.Errorf("unexpected key %s", key)
+		return true
+	})
+}
+
+// Run with -race to catch unsynchronised access.
+func TestStorageClient_ConcurrentAccess(t *testing.T) {
+	const (
+		workers = 16
+		keys    = 200
+	)
+
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				key := fmt.Sprintf("users:%d", i)
+				switch (w + i) % 4 {
+				case 0:
+					_ = s.Save(ctx, key, w)
+				case 1:
+					_, _ = s.Get(ctx, key)
+				case 2:
+					_ = s.Delete(ctx, key)
+				default:
+					s.Scan(ctx, "users:1", func(string, interface{}) bool { return true })
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := 0; i < keys; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("users:%d", i), i))
+	}
+
+	count := 0
+	s.Scan(ctx, "users:", func(string, interface{}) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, keys, count)
+}
+
+func BenchmarkStorageClient_ParallelGet(b *testing.B) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	for i := 0; i < 1000; i++ {
+		_ = s.Save(ctx, fmt.Sprintf("users:%d", i), i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = s.Get(ctx, fmt.Sprintf("users:%d", i%1000))
+			i++
+		}
+	})
+}
+
+func TestStorageClient_TTL(t *testing.T) {
+	now := time.Now()
+	s := InitConnection(WithDefaultTTL(time.Minute))
+	s.now = func() time.Time { return now }
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "default", 1))
+	assert.Nil(t, s.SaveWithTTL(ctx, "short", 2, time.Second))
+	assert.Nil(t, s.SaveWithTTL(ctx, "forever", 3, 0))
+
+	now = now.Add(2 * time.Second)
+	_, err := s.Get(ctx, "short")
+	assert.Equal(t, DataNotFoundErr, err)
+
+	data, err := s.Get(ctx, "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data)
+
+	now = now.Add(time.Hour)
+	_, err = s.Get(ctx, "default")
+	assert.Equal(t, DataNotFoundErr, err)
+	assert.Equal(t, DataNotFoundErr, s.Delete(ctx, "default"))
+
+	data, err = s.Get(ctx, "forever")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, data)
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(2), stats.Expirations)
+}
+
+func TestStorageClient_Purge(t *testing.T) {
+	now := time.Now()
+	s := InitConnection()
+	s.now = func() time.Time { return now }
+	ctx := context.NewBackgroundContext()
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, s.SaveWithTTL(ctx, fmt.Sprintf("users:%d", i), i, time.Duration(i+1)*time.Second))
+	}
+
+	now = now.Add(5 * time.Second)
+	s.Purge()
+
+	count := 0
+	for _, sh := range s.shards {
+		count += len(sh.items)
+	}
+	assert.Equal(t, 5, count)
+	assert.Equal(t, uint64(5), s.Stats().Expirations)
+}
+
+func TestStorageClient_Janitor(t *testing.T) {
+	s := InitConnection(WithJanitor(time.Millisecond))
+	defer s.Close()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.SaveWithTTL(ctx, "users:1", 1, time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return s.Stats().Expirations == 1
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, s.Close())
+}
+
+func TestStorageClient_MaxEntries(t *testing.T) {
+	for _, p := range []EvictionPolicy{LRU, LFU} {
+		p := p
+		t.Run(p.String(), func(t *testing.T) {
+			s := InitConnection(WithMaxEntries(100, p))
+			ctx := context.NewBackgroundContext()
+
+			for i := 0; i < 1000; i++ {
+				assert.Nil(t, s.Save(ctx, fmt.Sprintf("users:%d", i), i))
+			}
+
+			count := 0
+			s.Scan(ctx, "", func(string, interface{}) bool {
+				count++
+				return true
+			})
+			assert.LessOrEqual(t, count, 100)
+			assert.Equal(t, uint64(1000-count), s.Stats().Evictions)
+		})
+	}
+}
+
+func setItem(t *testing.T, sh *shard, it *item) int {
+	evicted, err := sh.set(it)
+	assert.Nil(t, err)
+	return evicted
+}
+
+func TestShard_LRU(t *testing.T) {
+	sh := newShard(3, LRU, newIndexes(), newWatchers())
+	now := time.Now()
+
+	setItem(t, sh, &item{key: "a"})
+	setItem(t, sh, &item{key: "b"})
+	setItem(t, sh, &item{key: "c"})
+	sh.get("a", now)
+
+	assert.Equal(t, 1, setItem(t, sh, &item{key: "d"}))
+	assert.NotContains(t, sh.items, "b")
+	assert.Contains(t, sh.items, "a")
+
+	setItem(t, sh, &item{key: "c", value: 1})
+	assert.Equal(t, 1, setItem(t, sh, &item{key: "e"}))
+	assert.NotContains(t, sh.items, "a")
+	assert.Len(t, sh.items, 3)
+}
+
+func TestShard_LFU(t *testing.T) {
+	sh := newShard(3, LFU, newIndexes(), newWatchers())
+	now := time.Now()
+
+	setItem(t, sh, &item{key: "a"})
+	setItem(t, sh, &item{key: "b"})
+	setItem(t, sh, &item{key: "c"})
+	sh.get("a", now)
+	sh.get("a", now)
+	sh.get("c", now)
+
+	assert.Equal(t, 1, setItem(t, sh, &item{key: "d"}))
+	assert.NotContains(t, sh.items, "b")
+
+	// d is the least used now, and ties are broken by recency.
+	assert.Equal(t, 1, setItem(t, sh, &item{key: "e"}))
+	assert.NotContains(t, sh.items, "d")
+	assert.Contains(t, sh.items, "a")
+	assert.Contains(t, sh.items, "c")
+}
+
+func TestStorageClient_Exists(t *testing.T) {
+	now := time.Now()
+	s := InitConnection()
+	s.now = func() time.Time { return now }
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "users:1", 1))
+	assert.Nil(t, s.SaveWithTTL(ctx, "users:2", 2, time.Second))
+
+	assert.True(t, s.Exists(ctx, "users:1"))
+	assert.True(t, s.Exists(ctx, "users:2"))
+	assert.False(t, s.Exists(ctx, "users:3"))
+
+	now = now.Add(time.Minute)
+	assert.False(t, s.Exists(ctx, "users:2"))
+	assert.Equal(t, 1, s.Len(ctx))
+	assert.Equal(t, Stats{}, s.Stats())
+}
+
+func TestStorageClient_GetManySaveMany(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.SaveMany(ctx, map[string]interface{}{
+		"users:1": 1,
+		"users:2": 2,
+		"users:3": 3,
+	}))
+	assert.Equal(t, 3, s.Len(ctx))
+
+	values, err := s.GetMany(ctx, "users:1", "users:3", "users:4")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"users:1": 1, "users:3": 3}, values)
+}
+
+func TestStorageClient_Keys(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "users:1", 1))
+	assert.Nil(t, s.Save(ctx, "users:2", 2))
+	assert.Nil(t, s.Save(ctx, "orders:1", 1))
+
+	assert.ElementsMatch(t, []string{"users:1", "users:2"}, s.Keys(ctx, "users:"))
+	assert.ElementsMatch(t, []string{"users:1", "users:2", "orders:1"}, s.Keys(ctx, ""))
+	assert.Empty(t, s.Keys(ctx, "payments:"))
+	assert.Equal(t, 3, s.Len(ctx))
+
+	assert.Nil(t, s.Delete(ctx, "users:1"))
+	assert.Equal(t, 2, s.Len(ctx))
+}
+
+func TestStorageClient_Traces(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := InitConnection(WithName("sessions"))
+	ctx := context.NewBackgroundContext()
+
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	_, _ = s.Get(ctx, "b")
+	assert.Equal(t, DataNotFoundErr, s.Delete(ctx, "b"))
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+
+	actions := make([]string, 0)
+	for _, span := range spans {
+		actions = append(actions, span.OperationName())
+		assert.Equal(t, ext.AppTypeCache, span.Tag(ext.SpanType))
+		assert.Equal(t, "sessions", span.Tag(ext.ResourceName))
+
+		// Misses are not failures.
+		assert.Nil(t, span.Tag(ext.Error))
+	}
+	assert.Equal(t, []string{setAction, getAction, deleteAction}, actions)
+}
+
+func TestStorageClient_NilContext(t *testing.T) {
+	s := InitConnection()
+
+	assert.Nil(t, s.Save(nil, "a", 1))
+	data, err := s.Get(nil, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data)
+
+	tx := s.Begin(nil)
+	assert.Nil(t, tx.Save(nil, "b", 2))
+	assert.Nil(t, tx.Commit())
+	assert.True(t, s.Exists(nil, "b"))
+
+	events := s.Watch(nil, "")
+	assert.Nil(t, s.Delete(nil, "a"))
+	assert.Equal(t, Event{Type: DeleteEvent, Key: "a", Value: 1}, <-events)
+}
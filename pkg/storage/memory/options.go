@@ -0,0 +1,91 @@
+package memory
+
+import "time"
+
+const (
+	defaultName        = "memory"
+	defaultWatchBuffer = 64
+)
+
+type (
+	options struct {
+		name       string
+		maxEntries int
+		eviction   EvictionPolicy
+		defaultTTL time.Duration
+		janitor    time.Duration
+
+		snapshot         string
+		snapshotInterval time.Duration
+		appendLog        string
+		codec            Codec
+
+		watchBuffer int
+	}
+
+	Option func(o *options)
+)
+
+// WithName tags the metrics emitted by the client, so several caches can be told apart.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithMaxEntries bounds the number of stored keys. Once reached, Save evicts following the policy.
+// The bound is enforced per shard, so evictions may start slightly before the total is reached.
+// NoEviction leaves the storage unbounded.
+func WithMaxEntries(n int, p EvictionPolicy) Option {
+	return func(o *options) {
+		o.maxEntries = n
+		o.eviction = p
+	}
+}
+
+// WithDefaultTTL sets the expiration used by Save. Zero keeps entries until they are deleted or evicted.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.defaultTTL = ttl
+	}
+}
+
+// WithJanitor starts a background goroutine that purges expired entries on every interval.
+// Without it, expired entries are only dropped when they are read. Stop it with Close.
+func WithJanitor(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitor = interval
+	}
+}
+
+// WithSnapshot restores the client from the file at path and writes a new snapshot on every
+// interval and on Close. A zero interval only snapshots on Close or when Snapshot is called.
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(o *options) {
+		o.snapshot = path
+		o.snapshotInterval = interval
+	}
+}
+
+// WithAppendLog logs every write to the file at path and replays it after the snapshot on start,
+// so writes made since the last snapshot are not lost. The log restarts with every snapshot.
+func WithAppendLog(path string) Option {
+	return func(o *options) {
+		o.appendLog = path
+	}
+}
+
+// WithCodec sets how values are encoded on disk. Defaults to GobCodec.
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithWatchBuffer sets how many events each watcher may have pending. A watcher that falls
+// further behind is disconnected, so writers never block on slow subscribers. Defaults to 64.
+func WithWatchBuffer(n int) Option {
+	return func(o *options) {
+		o.watchBuffer = n
+	}
+}
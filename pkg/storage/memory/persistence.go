@@ -0,0 +1,451 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/pedidosya/peya-go/logs"
+)
+
+const (
+	opPut byte = iota + 1
+	opDelete
+	opBatch
+
+	headerSize  = 8
+	rotatedExt  = ".old"
+	temporalExt = ".tmp"
+)
+
+var (
+	SnapshotDisabledErr = errors.New("memory snapshot path is not configured")
+
+	tornRecordErr = errors.New("memory record is incomplete or corrupted")
+)
+
+type (
+	// record is the unit written to snapshots and to the append log:
+	// a length and a checksum followed by the operation, expiration, key and encoded value.
+	record struct {
+		op      byte
+		expires int64
+		key     string
+		value   []byte
+	}
+
+	// journal appends every write to a log, so the state since the last snapshot survives a restart.
+	journal struct {
+		mu    sync.Mutex
+		path  string
+		f     *os.File
+		codec Codec
+	}
+)
+
+func openJournal(path string, codec Codec) (*journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{path: path, f: f, codec: codec}, nil
+}
+
+// put logs the item. A nil journal logs nothing.
+func (j *journal) put(it *item) error {
+	if j == nil {
+		return nil
+	}
+
+	data, err := j.codec.Marshal(it.value)
+	if err != nil {
+		return err
+	}
+	return j.write(record{op: opPut, expires: unixNano(it.expires), key: it.key, value: data})
+}
+
+// delete logs the removal of key. A nil journal logs nothing.
+func (j *journal) delete(key string) error {
+	if j == nil {
+		return nil
+	}
+	return j.write(record{op: opDelete, key: key})
+}
+
+// batch logs the writes of a transaction as a single record, so a crash replays all of them or none.
+// A nil journal logs nothing.
+func (j *journal) batch(writes []write) error {
+	if j == nil {
+		return nil
+	}
+
+	body := make([]byte, 0)
+	for _, w := range writes {
+		r := record{op: opDelete, key: w.key}
+		if w.it != nil {
+			data, err := j.codec.Marshal(w.it.value)
+			if err != nil {
+				return err
+			}
+			r = record{op: opPut, expires: unixNano(w.it.expires), key: w.key, value: data}
+		}
+		body = append(body, r.encode()...)
+	}
+	return j.write(record{op: opBatch, value: body})
+}
+
+func (j *journal) write(r record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err := j.f.Write(r.encode())
+	return err
+}
+
+// rotate moves the current log aside so a snapshot can supersede it, and starts an empty one.
+// If a previous snapshot failed, the rotated log is kept and the current one is appended to it.
+func (j *journal) rotate() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	rotated := j.path + rotatedExt
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(rotated); err == nil {
+		if err := appendFile(rotated, j.path); err != nil {
+			return err
+		}
+		if err := os.Remove(j.path); err != nil {
+			return err
+		}
+	} else if err := os.Rename(j.path, rotated); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	j.f = f
+	return nil
+}
+
+// release drops the rotated log once a snapshot holds its changes.
+func (j *journal) release() error {
+	if j == nil {
+		return nil
+	}
+
+	if err := os.Remove(j.path + rotatedExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.f.Sync(); err != nil {
+		return err
+	}
+	return j.f.Close()
+}
+
+// Snapshot writes every live entry to the snapshot file. The file is replaced atomically,
+// and the append log is restarted, so a restore only replays writes made after it.
+func (s *StorageClient) Snapshot() error {
+	if len(s.opts.snapshot) == 0 {
+		return SnapshotDisabledErr
+	}
+
+	s.snapshotting.Lock()
+	defer s.snapshotting.Unlock()
+
+	if err := s.journal.rotate(); err != nil {
+		return err
+	}
+
+	tmp := s.opts.snapshot + temporalExt
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeSnapshot(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, s.opts.snapshot); err != nil {
+		return err
+	}
+	return s.journal.release()
+}
+
+func (s *StorageClient) writeSnapshot(f *os.File) error {
+	w := bufio.NewWriter(f)
+	now := s.now()
+
+	for _, sh := range s.shards {
+		for _, it := range sh.live(now) {
+			data, err := s.opts.codec.Marshal(it.value)
+			if err != nil {
+				return err
+			}
+
+			r := record{op: opPut, expires: unixNano(it.expires), key: it.key, value: data}
+			if _, err := w.Write(r.encode()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// restore loads the snapshot and replays the logs written after it.
+func (s *StorageClient) restore() error {
+	if len(s.opts.snapshot) > 0 {
+		if err := s.replay(s.opts.snapshot); err != nil {
+			return err
+		}
+	}
+
+	if len(s.opts.appendLog) > 0 {
+		if err := s.replay(s.opts.appendLog + rotatedExt); err != nil {
+			return err
+		}
+		if err := s.replay(s.opts.appendLog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay applies every record of the file. A torn tail, left by a crash mid-write, is cut off.
+func (s *StorageClient) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := s.now()
+	r := bufio.NewReader(f)
+	var offset int64
+
+	for {
+		rec, size, err := readRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err == tornRecordErr {
+			return os.Truncate(path, offset)
+		}
+
+		if err != nil {
+			return err
+		}
+		offset += size
+
+		if err := s.apply(rec, now); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *StorageClient) apply(rec record, now time.Time) error {
+	if rec.op == opBatch {
+		r := bufio.NewReader(bytes.NewReader(rec.value))
+		for {
+			inner, _, err := readRecord(r)
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if err := s.apply(inner, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	sh := s.shard(rec.key)
+	if rec.op == opDelete {
+		_, err := sh.remove(rec.key, now)
+		return err
+	}
+
+	value, err := s.opts.codec.Unmarshal(rec.value)
+	if err != nil {
+		return err
+	}
+
+	it := &item{key: rec.key, value: value}
+	if rec.expires > 0 {
+		it.expires = time.Unix(0, rec.expires)
+	}
+
+	if it.expired(now) {
+		_, err := sh.remove(rec.key, now)
+		return err
+	}
+
+	_, err = sh.set(it)
+	return err
+}
+
+func (s *StorageClient) snapshots(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				log.Error("[Snapshot] fail writing memory snapshot", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (r record) encode() []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	body := make([]byte, 0, 1+binary.MaxVarintLen64*3+len(r.key)+len(r.value))
+	body = append(body, r.op)
+	body = append(body, scratch[:binary.PutVarint(scratch[:], r.expires)]...)
+	body = append(body, scratch[:binary.PutUvarint(scratch[:], uint64(len(r.key)))]...)
+	body = append(body, r.key...)
+	body = append(body, scratch[:binary.PutUvarint(scratch[:], uint64(len(r.value)))]...)
+	body = append(body, r.value...)
+
+	out := make([]byte, headerSize, headerSize+len(body))
+	binary.BigEndian.PutUint32(out[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(out[4:8], crc32.ChecksumIEEE(body))
+	return append(out, body...)
+}
+
+// readRecord returns the next record and the bytes it took. A clean end of file is io.EOF.
+func readRecord(r *bufio.Reader) (record, int64, error) {
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF {
+		return record{}, 0, io.EOF
+	}
+	if err != nil || n < headerSize {
+		return record{}, 0, tornRecordErr
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return record{}, 0, tornRecordErr
+	}
+
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:8]) {
+		return record{}, 0, tornRecordErr
+	}
+
+	rec, err := decodeRecord(body)
+	if err != nil {
+		return record{}, 0, err
+	}
+	return rec, int64(headerSize + len(body)), nil
+}
+
+func decodeRecord(body []byte) (record, error) {
+	if len(body) == 0 {
+		return record{}, tornRecordErr
+	}
+
+	rec := record{op: body[0]}
+	body = body[1:]
+
+	expires, n := binary.Varint(body)
+	if n <= 0 {
+		return record{}, tornRecordErr
+	}
+	rec.expires = expires
+	body = body[n:]
+
+	key, body, err := readBytes(body)
+	if err != nil {
+		return record{}, err
+	}
+	rec.key = string(key)
+
+	value, _, err := readBytes(body)
+	if err != nil {
+		return record{}, err
+	}
+	rec.value = value
+	return rec, nil
+}
+
+func readBytes(body []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(body)
+	if n <= 0 || uint64(len(body)-n) < size {
+		return nil, nil, tornRecordErr
+	}
+	body = body[n:]
+	return body[:size], body[size:], nil
+}
+
+func appendFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gob.Register(person{})
+}
+
+func TestStorageClient_SnapshotRestore(t *testing.T) {
+	for name, codec := range map[string]Codec{"gob": GobCodec{}, "json": NewJSONCodec(person{}, "")} {
+		codec := codec
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "memory.snapshot")
+			ctx := context.NewBackgroundContext()
+
+			s, err := NewStorageClient(WithSnapshot(path, 0), WithCodec(codec))
+			require.Nil(t, err)
+			assert.Nil(t, s.Save(ctx, "people:1", person{Name: "ana", Age: 30}))
+			assert.Nil(t, s.Save(ctx, "greeting", "hello"))
+			assert.Nil(t, s.Snapshot())
+
+			restored, err := NewStorageClient(WithSnapshot(path, 0), WithCodec(codec))
+			require.Nil(t, err)
+
+			data, err := restored.Get(ctx, "people:1")
+			assert.Nil(t, err)
+			assert.Equal(t, person{Name: "ana", Age: 30}, data)
+
+			data, err = restored.Get(ctx, "greeting")
+			assert.Nil(t, err)
+			assert.Equal(t, "hello", data)
+		})
+	}
+}
+
+func TestStorageClient_AppendLogRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	// The first client is never closed, as if the process crashed.
+	s, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "people:1", person{Name: "ana"}))
+	assert.Nil(t, s.Save(ctx, "people:2", person{Name: "bob"}))
+	assert.Nil(t, s.Save(ctx, "people:1", person{Name: "carla"}))
+	assert.Nil(t, s.Delete(ctx, "people:2"))
+
+	restored, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"people:1"}, restored.Keys(ctx, ""))
+	data, err := restored.Get(ctx, "people:1")
+	assert.Nil(t, err)
+	assert.Equal(t, person{Name: "carla"}, data)
+}
+
+func TestStorageClient_SnapshotAndAppendLog(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "memory.snapshot")
+	appendLog := filepath.Join(dir, "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithSnapshot(snapshot, 0), WithAppendLog(appendLog))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Nil(t, s.Save(ctx, "b", 2))
+	assert.Nil(t, s.Snapshot())
+
+	info, err := os.Stat(appendLog)
+	require.Nil(t, err)
+	assert.Zero(t, info.Size())
+	_, err = os.Stat(appendLog + rotatedExt)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Nil(t, s.Delete(ctx, "a"))
+	assert.Nil(t, s.Save(ctx, "c", 3))
+
+	restored, err := NewStorageClient(WithSnapshot(snapshot, 0), WithAppendLog(appendLog))
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{"b", "c"}, restored.Keys(ctx, ""))
+}
+
+func TestStorageClient_CloseSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.snapshot")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithSnapshot(path, time.Hour))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Nil(t, s.Close())
+
+	restored, err := NewStorageClient(WithSnapshot(path, 0))
+	require.Nil(t, err)
+	assert.Equal(t, 1, restored.Len(ctx))
+}
+
+func TestStorageClient_RestoreSkipsExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Nil(t, s.SaveWithTTL(ctx, "short", 1, time.Millisecond))
+	assert.Nil(t, s.SaveWithTTL(ctx, "long", 2, time.Hour))
+	time.Sleep(5 * time.Millisecond)
+
+	restored, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"long"}, restored.Keys(ctx, ""))
+}
+
+func TestStorageClient_RestoreTornLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Nil(t, s.Save(ctx, "b", 2))
+
+	// Cut the last record in half, as a crash during the write would.
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	require.Nil(t, os.Truncate(path, info.Size()-3))
+
+	restored, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"a"}, restored.Keys(ctx, ""))
+
+	// Writes after the cut replay cleanly.
+	assert.Nil(t, restored.Save(ctx, "c", 3))
+	again, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{"a", "c"}, again.Keys(ctx, ""))
+}
+
+func TestStorageClient_AppendLogEvictions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path), WithMaxEntries(1, LRU))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Nil(t, s.Save(ctx, "b", 2))
+
+	restored, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"b"}, restored.Keys(ctx, ""))
+}
+
+func TestStorageClient_PersistenceErrors(t *testing.T) {
+	ctx := context.NewBackgroundContext()
+
+	s := InitConnection()
+	assert.Equal(t, SnapshotDisabledErr, s.Snapshot())
+
+	path := filepath.Join(t.TempDir(), "memory.log")
+	s, err := NewStorageClient(WithAppendLog(path), WithCodec(NewJSONCodec(person{})))
+	require.Nil(t, err)
+	assert.Equal(t, CodecUnknownTypeErr, s.Save(ctx, "a", 1))
+	assert.False(t, s.Exists(ctx, "a"))
+}
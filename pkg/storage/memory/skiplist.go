@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"math/rand"
+	"strings"
+)
+
+const (
+	maxLevel    = 24
+	probability = 0.25
+)
+
+type (
+	// skiplist keeps index entries ordered by indexed value and then by key,
+	// so equal values from different keys live side by side.
+	skiplist struct {
+		head  *node
+		level int
+		len   int
+		rnd   *rand.Rand
+	}
+
+	node struct {
+		value interface{}
+		it    *item
+		next  []*node
+	}
+)
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  &node{next: make([]*node, maxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// insert adds the item under value, replacing the entry of the same key and value if present.
+func (l *skiplist) insert(value interface{}, it *item) {
+	update := l.path(value, it.key)
+
+	if n := update[0].next[0]; n != nil && l.compare(n, value, it.key) == 0 {
+		n.it = it
+		return
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			update[i] = l.head
+		}
+		l.level = level
+	}
+
+	n := &node{value: value, it: it, next: make([]*node, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	l.len++
+}
+
+// delete removes the entry of key under value, if present.
+func (l *skiplist) delete(value interface{}, key string) {
+	update := l.path(value, key)
+
+	n := update[0].next[0]
+	if n == nil || l.compare(n, value, key) != 0 {
+		return
+	}
+
+	for i := 0; i < l.level; i++ {
+		if update[i].next[i] != n {
+			break
+		}
+		update[i].next[i] = n.next[i]
+	}
+
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.len--
+}
+
+// seek returns the first node whose value is not below from, or above it when exclusive.
+func (l *skiplist) seek(from interface{}, exclusive bool) *node {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			c := compareValues(x.next[i].value, from)
+			if c < 0 || (exclusive && c == 0) {
+				x = x.next[i]
+				continue
+			}
+			break
+		}
+	}
+	return x.next[0]
+}
+
+func (l *skiplist) first() *node {
+	return l.head.next[0]
+}
+
+// path returns, for every level, the last node ordered before (value, key).
+func (l *skiplist) path(value interface{}, key string) []*node {
+	update := make([]*node, maxLevel)
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && l.compare(x.next[i], value, key) < 0 {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	return update
+}
+
+func (l *skiplist) compare(n *node, value interface{}, key string) int {
+	if c := compareValues(n.value, value); c != 0 {
+		return c
+	}
+	return strings.Compare(n.it.key, key)
+}
+
+func (l *skiplist) randomLevel() int {
+	level := 1
+	for level < maxLevel && l.rnd.Float64() < probability {
+		level++
+	}
+	return level
+}
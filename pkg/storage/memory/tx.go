@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"errors"
+	"go-dao-pattern/pkg/context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	TxDoneErr     = errors.New("memory transaction has already been committed or rolled back")
+	TxConflictErr = errors.New("memory transaction read data changed by another writer")
+)
+
+type (
+	// Tx stages writes until Commit. Nothing it writes is visible to other readers before then.
+	// A commit is applied under the locks of every shard it touches, so a single-key read sees a key
+	// either before or after the commit. Reads spanning many keys, such as Scan, Keys, Len, GetMany
+	// and Range, visit the shards one at a time and may observe a commit partially applied.
+	// Commit fails with TxConflictErr if an entry the transaction read was changed since.
+	// A Tx is safe for concurrent use.
+	Tx struct {
+		mu      sync.Mutex
+		client  *StorageClient
+		ctx     *context.Context
+		reads   map[string]*item
+		writes  map[string]int
+		pending []write
+		done    bool
+	}
+
+	// write is a staged change. A nil item deletes the key.
+	write struct {
+		key string
+		it  *item
+	}
+)
+
+// Begin starts a transaction. If ctx is done before Commit, the transaction is rolled back.
+func (s *StorageClient) Begin(ctx *context.Context) *Tx {
+	return &Tx{
+		client: s,
+		ctx:    ctx,
+		reads:  make(map[string]*item),
+		writes: make(map[string]int),
+	}
+}
+
+// Get returns the value staged by the transaction, or else the committed one.
+func (tx *Tx) Get(ctx *context.Context, key string) (interface{}, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return nil, TxDoneErr
+	}
+
+	it := tx.read(key)
+	if it == nil {
+		return nil, DataNotFoundErr
+	}
+	return it.value, nil
+}
+
+// Exists reports whether a live entry is visible to the transaction under key.
+func (tx *Tx) Exists(ctx *context.Context, key string) (bool, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return false, TxDoneErr
+	}
+	return tx.read(key) != nil, nil
+}
+
+// Save stages the value using the default TTL of the client.
+func (tx *Tx) Save(ctx *context.Context, key string, value interface{}) error {
+	return tx.SaveWithTTL(ctx, key, value, tx.client.opts.defaultTTL)
+}
+
+// SaveWithTTL stages the value. The TTL starts counting when the value is staged.
+func (tx *Tx) SaveWithTTL(ctx *context.Context, key string, value interface{}, ttl time.Duration) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return TxDoneErr
+	}
+
+	it := &item{key: key, value: value}
+	if ttl > 0 {
+		it.expires = tx.client.now().Add(ttl)
+	}
+
+	tx.stage(write{key: key, it: it})
+	return nil
+}
+
+// Delete stages the removal of key, and fails if no entry is visible to the transaction under it.
+func (tx *Tx) Delete(ctx *context.Context, key string) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return TxDoneErr
+	}
+
+	if tx.read(key) == nil {
+		return DataNotFoundErr
+	}
+
+	tx.stage(write{key: key})
+	return nil
+}
+
+// Commit applies the staged writes atomically, logging them first when an append log is configured.
+func (tx *Tx) Commit() error {
+	var err error
+	tx.client.trace(tx.ctx, commitAction, func() error {
+		err = tx.commit()
+		return err
+	})
+	return err
+}
+
+func (tx *Tx) commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return TxDoneErr
+	}
+	tx.done = true
+
+	if c, _ := parent(tx.ctx); c.Err() != nil {
+		return c.Err()
+	}
+
+	if len(tx.pending) == 0 {
+		return nil
+	}
+
+	s := tx.client
+	shards := tx.shards()
+	for _, sh := range shards {
+		sh.mu.Lock()
+	}
+	defer func() {
+		for _, sh := range shards {
+			sh.mu.Unlock()
+		}
+	}()
+
+	now := s.now()
+	for key, seen := range tx.reads {
+		if s.shard(key).current(key, now) != seen {
+			return TxConflictErr
+		}
+	}
+
+	// The evictions are planned and logged along with the writes, so nothing can fail once the batch is
+	// logged and the commit is applied whole. Every shard shares the same journal, and holding its lock
+	// keeps Close from detaching it.
+	victims, overflow := tx.evictions()
+	batch := make([]write, 0, len(victims)+len(tx.pending)+len(overflow))
+	for _, it := range victims {
+		batch = append(batch, write{key: it.key})
+	}
+	batch = append(batch, tx.pending...)
+	for _, key := range overflow {
+		batch = append(batch, write{key: key})
+	}
+
+	if err := shards[0].journal.batch(batch); err != nil {
+		return err
+	}
+
+	for _, it := range victims {
+		s.shard(it.key).drop(it)
+	}
+
+	for _, w := range tx.pending {
+		sh := s.shard(w.key)
+		if w.it == nil {
+			if it, found := sh.items[w.key]; found {
+				sh.drop(it)
+			}
+			continue
+		}
+		sh.insert(w.it)
+	}
+
+	for _, key := range overflow {
+		sh := s.shard(key)
+		sh.drop(sh.items[key])
+	}
+
+	if evicted := len(victims) + len(overflow); evicted > 0 {
+		s.count(&s.stats.Evictions, evictionsMetric, evicted)
+	}
+	return nil
+}
+
+// evictions plans the room the staged writes need in every bounded shard. Entries the transaction does
+// not write leave first, in the order of the eviction policy. If they are not enough, the overflow keys
+// are the earliest staged ones, which are stored and evicted right away. Callers hold the shard locks.
+func (tx *Tx) evictions() ([]*item, []string) {
+	byShard := make(map[*shard][]write)
+	for _, w := range tx.pending {
+		sh := tx.client.shard(w.key)
+		byShard[sh] = append(byShard[sh], w)
+	}
+
+	victims := make([]*item, 0)
+	overflow := make([]string, 0)
+	for sh, writes := range byShard {
+		if sh.capacity <= 0 {
+			continue
+		}
+
+		written := make(map[string]bool, len(writes))
+		size := len(sh.items)
+		for _, w := range writes {
+			written[w.key] = true
+			if _, found := sh.items[w.key]; found {
+				size--
+			}
+			if w.it != nil {
+				size++
+			}
+		}
+
+		needed := size - sh.capacity
+		if needed <= 0 {
+			continue
+		}
+
+		vs := sh.policy.victims(needed, func(it *item) bool {
+			return written[it.key]
+		})
+		victims = append(victims, vs...)
+
+		for _, w := range writes {
+			if len(vs) >= needed {
+				break
+			}
+			if w.it != nil {
+				overflow = append(overflow, w.key)
+				needed--
+			}
+		}
+	}
+	return victims, overflow
+}
+
+// Rollback discards the staged writes.
+func (tx *Tx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return TxDoneErr
+	}
+
+	tx.done = true
+	tx.pending = nil
+	return nil
+}
+
+// read returns the item visible to the transaction, remembering the committed one for Commit to validate.
+func (tx *Tx) read(key string) *item {
+	if i, staged := tx.writes[key]; staged {
+		it := tx.pending[i].it
+		if it == nil || it.expired(tx.client.now()) {
+			return nil
+		}
+		return it
+	}
+
+	if it, seen := tx.reads[key]; seen {
+		return it
+	}
+
+	it := tx.client.shard(key).peek(key, tx.client.now())
+	tx.reads[key] = it
+	return it
+}
+
+// stage records the write, replacing an earlier one for the same key.
+func (tx *Tx) stage(w write) {
+	if i, staged := tx.writes[w.key]; staged {
+		tx.pending[i] = w
+		return
+	}
+
+	tx.writes[w.key] = len(tx.pending)
+	tx.pending = append(tx.pending, w)
+}
+
+// shards returns the shards touched by the transaction in a fixed order, so concurrent commits lock them without deadlocking.
+func (tx *Tx) shards() []*shard {
+	seen := make(map[int]bool)
+	for key := range tx.reads {
+		seen[tx.client.shardIndex(key)] = true
+	}
+	for _, w := range tx.pending {
+		seen[tx.client.shardIndex(w.key)] = true
+	}
+
+	indexes := make([]int, 0, len(seen))
+	for i := range seen {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	shards := make([]*shard, len(indexes))
+	for i, idx := range indexes {
+		shards[i] = tx.client.shards[idx]
+	}
+	return shards
+}
@@ -0,0 +1,267 @@
+package memory
+
+import (
+	stdcontext "context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx_Commit(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Nil(t, s.Save(ctx, "b", 2))
+
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "a", 10))
+	assert.Nil(t, tx.Save(ctx, "c", 3))
+	assert.Nil(t, tx.Delete(ctx, "b"))
+
+	// The transaction sees its own writes.
+	data, err := tx.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, data)
+	_, err = tx.Get(ctx, "b")
+	assert.Equal(t, DataNotFoundErr, err)
+
+	// Other readers do not, until it commits.
+	data, err = s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data)
+	assert.True(t, s.Exists(ctx, "b"))
+	assert.False(t, s.Exists(ctx, "c"))
+
+	assert.Nil(t, tx.Commit())
+
+	data, err = s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, data)
+	assert.False(t, s.Exists(ctx, "b"))
+	assert.True(t, s.Exists(ctx, "c"))
+
+	assert.Equal(t, TxDoneErr, tx.Commit())
+	assert.Equal(t, TxDoneErr, tx.Rollback())
+	assert.Equal(t, TxDoneErr, tx.Save(ctx, "d", 4))
+}
+
+func TestTx_Rollback(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.Save(ctx, "a", 1))
+
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "a", 10))
+	assert.Nil(t, tx.Save(ctx, "b", 2))
+	assert.Nil(t, tx.Rollback())
+
+	data, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data)
+	assert.False(t, s.Exists(ctx, "b"))
+	assert.Equal(t, TxDoneErr, tx.Commit())
+}
+
+func TestTx_Conflict(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.Save(ctx, "a", 1))
+
+	tx := s.Begin(ctx)
+	_, err := tx.Get(ctx, "a")
+	assert.Nil(t, err)
+	found, err := tx.Exists(ctx, "b")
+	assert.Nil(t, err)
+	assert.False(t, found)
+	assert.Nil(t, tx.Save(ctx, "c", 3))
+
+	// A concurrent writer creates a key the transaction saw missing.
+	assert.Nil(t, s.Save(ctx, "b", 2))
+
+	assert.Equal(t, TxConflictErr, tx.Commit())
+	assert.False(t, s.Exists(ctx, "c"))
+
+	// Blind writes never conflict.
+	tx = s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "a", 10))
+	assert.Nil(t, s.Save(ctx, "a", 100))
+	assert.Nil(t, tx.Commit())
+
+	data, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, data)
+}
+
+func TestTx_Errors(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	tx := s.Begin(ctx)
+	assert.Equal(t, DataNotFoundErr, tx.Delete(ctx, "a"))
+	assert.Nil(t, tx.Rollback())
+
+	c, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancelled := context.NewWebContext(httptest.NewRequest(http.MethodPost, "/", nil).WithContext(c))
+	tx = s.Begin(cancelled)
+	assert.Nil(t, tx.Save(ctx, "a", 1))
+	cancel()
+
+	assert.Equal(t, stdcontext.Canceled, tx.Commit())
+	assert.False(t, s.Exists(ctx, "a"))
+}
+
+func TestTx_Indexes(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.CreateIndex("age", "people:", ageIndex))
+	assert.Nil(t, s.Save(ctx, "people:1", person{Age: 10}))
+
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "people:1", person{Age: 30}))
+	assert.Nil(t, tx.Save(ctx, "people:2", person{Age: 20}))
+	assert.Equal(t, []string{"people:1"}, keysInRange(t, s, "age", Range{}))
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, []string{"people:2", "people:1"}, keysInRange(t, s, "age", Range{}))
+}
+
+func TestTx_AppendLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "b", 2))
+	assert.Nil(t, tx.Delete(ctx, "a"))
+	assert.Nil(t, tx.Commit())
+
+	tx = s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "c", 3))
+	assert.Nil(t, tx.Rollback())
+
+	restored, err := NewStorageClient(WithAppendLog(path))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"b"}, restored.Keys(ctx, ""))
+}
+
+func TestTx_Eviction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path), WithMaxEntries(1, LRU))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+
+	// The commit needs two evictions: a, then b, the earliest staged key.
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "b", 2))
+	assert.Nil(t, tx.Save(ctx, "c", 3))
+	assert.Nil(t, tx.Commit())
+
+	assert.Equal(t, []string{"c"}, s.Keys(ctx, ""))
+	assert.Equal(t, uint64(2), s.Stats().Evictions)
+
+	restored, err := NewStorageClient(WithAppendLog(path), WithMaxEntries(1, LRU))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"c"}, restored.Keys(ctx, ""))
+}
+
+func TestTx_EvictionOrder(t *testing.T) {
+	for _, p := range []EvictionPolicy{LRU, LFU} {
+		p := p
+		t.Run(p.String(), func(t *testing.T) {
+			s := InitConnection(WithMaxEntries(1, p))
+			s.shards[0].capacity = 3
+			ctx := context.NewBackgroundContext()
+
+			assert.Nil(t, s.Save(ctx, "a", 1))
+			assert.Nil(t, s.Save(ctx, "b", 2))
+			assert.Nil(t, s.Save(ctx, "c", 3))
+			_, err := s.Get(ctx, "a")
+			assert.Nil(t, err)
+
+			// b would be the victim, but the transaction writes it.
+			tx := s.Begin(ctx)
+			assert.Nil(t, tx.Save(ctx, "b", 20))
+			assert.Nil(t, tx.Save(ctx, "d", 4))
+			assert.Nil(t, tx.Commit())
+
+			assert.ElementsMatch(t, []string{"a", "b", "d"}, s.Keys(ctx, ""))
+			assert.Equal(t, uint64(1), s.Stats().Evictions)
+		})
+	}
+}
+
+func TestTx_CommitLogError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.log")
+	ctx := context.NewBackgroundContext()
+
+	s, err := NewStorageClient(WithAppendLog(path), WithMaxEntries(1, LRU))
+	require.Nil(t, err)
+	assert.Nil(t, s.Save(ctx, "a", 1))
+
+	// Functions cannot be encoded, so nothing is logged nor applied, not even the eviction of a.
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "b", func() {}))
+	assert.NotNil(t, tx.Commit())
+
+	assert.Equal(t, []string{"a"}, s.Keys(ctx, ""))
+	assert.Equal(t, uint64(0), s.Stats().Evictions)
+
+	restored, err := NewStorageClient(WithAppendLog(path), WithMaxEntries(1, LRU))
+	require.Nil(t, err)
+	assert.Equal(t, []string{"a"}, restored.Keys(ctx, ""))
+}
+
+// Concurrent transfers between accounts retry on conflict and keep the total constant.
+func TestTx_ConcurrentTransfers(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+
+	const accounts = 8
+	for i := 0; i < accounts; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprintf("account:%d", i), 100))
+	}
+
+	transfer := func(from, to string) error {
+		tx := s.Begin(ctx)
+		a, _ := tx.Get(ctx, from)
+		b, _ := tx.Get(ctx, to)
+		_ = tx.Save(ctx, from, a.(int)-1)
+		_ = tx.Save(ctx, to, b.(int)+1)
+		return tx.Commit()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				from := fmt.Sprintf("account:%d", (w+i)%accounts)
+				to := fmt.Sprintf("account:%d", (w+i+1)%accounts)
+				for transfer(from, to) == TxConflictErr {
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	total := 0
+	s.Scan(ctx, "account:", func(_ string, v interface{}) bool {
+		total += v.(int)
+		return true
+	})
+	assert.Equal(t, accounts*100, total)
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"go-dao-pattern/pkg/context"
+	"strings"
+	"sync"
+)
+
+const (
+	PutEvent EventType = iota + 1
+	DeleteEvent
+)
+
+type (
+	EventType int
+
+	// Event describes a change to a key. Deletes carry the value that was removed, whether
+	// it was deleted, evicted or expired.
+	Event struct {
+		Type  EventType
+		Key   string
+		Value interface{}
+	}
+
+	watchers struct {
+		mu  sync.RWMutex
+		set map[*watcher]struct{}
+	}
+
+	watcher struct {
+		mu     sync.Mutex
+		prefix string
+		ch     chan Event
+		done   chan struct{}
+		closed bool
+	}
+)
+
+func (t EventType) String() string {
+	switch t {
+	case PutEvent:
+		return "put"
+	case DeleteEvent:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+func newWatchers() *watchers {
+	return &watchers{set: make(map[*watcher]struct{})}
+}
+
+// Watch returns the changes made to keys starting with prefix from now on. Events of the same key
+// arrive in the order they happened; there is no ordering across keys.
+// The channel is closed when ctx is done or the client is closed. Writers never wait for watchers:
+// one that lets more events than the watch buffer pile up is disconnected and its channel closed,
+// so a channel closed while ctx is still alive means events were missed and local state should be rebuilt.
+func (s *StorageClient) Watch(ctx *context.Context, prefix string) <-chan Event {
+	w := &watcher{
+		prefix: prefix,
+		ch:     make(chan Event, s.opts.watchBuffer),
+		done:   make(chan struct{}),
+	}
+	s.watchers.add(w)
+
+	c, _ := parent(ctx)
+	cancelled := c.Done()
+
+	go func() {
+		select {
+		case <-cancelled:
+		case <-w.done:
+		case <-s.done:
+		}
+		s.watchers.remove(w)
+		w.close()
+	}()
+	return w.ch
+}
+
+func (ws *watchers) add(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.set[w] = struct{}{}
+}
+
+func (ws *watchers) remove(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	delete(ws.set, w)
+}
+
+// notify hands the event to every matching watcher. Callers hold the shard lock, which keeps the events of a key in order.
+func (ws *watchers) notify(e Event) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	for w := range ws.set {
+		if strings.HasPrefix(e.Key, w.prefix) {
+			w.send(e)
+		}
+	}
+}
+
+func (w *watcher) send(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.ch <- e:
+	default:
+		w.shutdown()
+	}
+}
+
+func (w *watcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.shutdown()
+	}
+}
+
+func (w *watcher) shutdown() {
+	w.closed = true
+	close(w.ch)
+	close(w.done)
+}
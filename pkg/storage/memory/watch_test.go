@@ -0,0 +1,137 @@
+package memory
+
+import (
+	stdcontext "context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-dao-pattern/pkg/context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, events <-chan Event) Event {
+	select {
+	case e, ok := <-events:
+		assert.True(t, ok, "watch channel closed")
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func closed(t *testing.T, events <-chan Event) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func cancellable() (*context.Context, stdcontext.CancelFunc) {
+	c, cancel := stdcontext.WithCancel(stdcontext.Background())
+	return context.NewWebContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(c)), cancel
+}
+
+func TestStorageClient_Watch(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	events := s.Watch(ctx, "people:")
+
+	assert.Nil(t, s.Save(ctx, "people:1", "ana"))
+	assert.Nil(t, s.Save(ctx, "pets:1", "rex"))
+	assert.Nil(t, s.Save(ctx, "people:1", "carla"))
+	assert.Nil(t, s.Delete(ctx, "people:1"))
+
+	assert.Equal(t, Event{Type: PutEvent, Key: "people:1", Value: "ana"}, receive(t, events))
+	assert.Equal(t, Event{Type: PutEvent, Key: "people:1", Value: "carla"}, receive(t, events))
+	assert.Equal(t, Event{Type: DeleteEvent, Key: "people:1", Value: "carla"}, receive(t, events))
+	assert.Empty(t, events)
+}
+
+func TestStorageClient_WatchExpirationsAndEvictions(t *testing.T) {
+	now := time.Now()
+	s := InitConnection(WithMaxEntries(1, LRU))
+	s.now = func() time.Time { return now }
+	ctx := context.NewBackgroundContext()
+	events := s.Watch(ctx, "")
+
+	assert.Nil(t, s.SaveWithTTL(ctx, "a", 1, time.Second))
+	assert.Equal(t, PutEvent, receive(t, events).Type)
+
+	now = now.Add(time.Minute)
+	s.Purge()
+	assert.Equal(t, Event{Type: DeleteEvent, Key: "a", Value: 1}, receive(t, events))
+
+	assert.Nil(t, s.Save(ctx, "b", 2))
+	assert.Nil(t, s.Save(ctx, "c", 3))
+	assert.Equal(t, Event{Type: PutEvent, Key: "b", Value: 2}, receive(t, events))
+	assert.Equal(t, Event{Type: DeleteEvent, Key: "b", Value: 2}, receive(t, events))
+	assert.Equal(t, Event{Type: PutEvent, Key: "c", Value: 3}, receive(t, events))
+}
+
+func TestStorageClient_WatchTx(t *testing.T) {
+	s := InitConnection()
+	ctx := context.NewBackgroundContext()
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	events := s.Watch(ctx, "")
+
+	tx := s.Begin(ctx)
+	assert.Nil(t, tx.Save(ctx, "b", 2))
+	assert.Nil(t, tx.Delete(ctx, "a"))
+	assert.Empty(t, events)
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, Event{Type: PutEvent, Key: "b", Value: 2}, receive(t, events))
+	assert.Equal(t, Event{Type: DeleteEvent, Key: "a", Value: 1}, receive(t, events))
+}
+
+func TestStorageClient_WatchCancel(t *testing.T) {
+	s := InitConnection()
+	ctx, cancel := cancellable()
+	events := s.Watch(ctx, "")
+	other := s.Watch(context.NewBackgroundContext(), "")
+
+	cancel()
+	assert.True(t, closed(t, events))
+
+	// Other watchers keep receiving.
+	assert.Nil(t, s.Save(ctx, "a", 1))
+	assert.Equal(t, PutEvent, receive(t, other).Type)
+
+	assert.Nil(t, s.Close())
+	assert.True(t, closed(t, other))
+	assert.True(t, closed(t, s.Watch(context.NewBackgroundContext(), "")))
+}
+
+func TestStorageClient_WatchSlowSubscriber(t *testing.T) {
+	s := InitConnection(WithWatchBuffer(4))
+	ctx := context.NewBackgroundContext()
+	slow := s.Watch(ctx, "")
+
+	// Writers are not held back by a subscriber that never reads.
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, s.Save(ctx, fmt.Sprint(i), i))
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, fmt.Sprint(i), receive(t, slow).Key)
+	}
+	assert.True(t, closed(t, slow))
+
+	assert.Eventually(t, func() bool {
+		s.watchers.mu.RLock()
+		defer s.watchers.mu.RUnlock()
+		return len(s.watchers.set) == 0
+	}, time.Second, time.Millisecond)
+}
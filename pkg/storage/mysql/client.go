@@ -17,14 +17,55 @@ type (
 		ConnMaxOpen     int
 		ConnMaxIdle     int
 		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
+
+		// Socket connects through a Unix socket instead of Host and Port.
+		Socket string
+		// Charset and Collation default to utf8mb4 and utf8mb4_general_ci.
+		Charset   string
+		Collation string
+		// Loc is the time zone DATETIME and TIMESTAMP values are read in. Defaults to UTC.
+		Loc *time.Location
+		// TLS enables encrypted connections. Nil connects in plain text.
+		TLS *TLSOptions
+
+		DialTimeout  time.Duration
+		ReadTimeout  time.Duration
+		WriteTimeout time.Duration
+
+		// Params are passed to the driver as is. Unknown ones are set as session variables.
+		Params map[string]string
+
+		// StmtCacheSize bounds the prepared statements kept per server. Zero keeps 128,
+		// and a negative size disables the cache.
+		StmtCacheSize int
+
+		// Replicas serve Query and QueryRow, spread by ReplicaBalance. Ejected replicas are
+		// pinged every ReplicaCheckInterval, 5 seconds by default, until they answer again.
+		Replicas             []Replica
+		ReplicaBalance       Balance
+		ReplicaCheckInterval time.Duration
+
+		// StatsInterval publishes the pool statistics as gauges tagged with RegisterName on every
+		// interval. Zero disables the reporter.
+		StatsInterval time.Duration
+
+		// ConnectTimeout bounds the connection setup, retries included. Zero leaves it to the context.
+		ConnectTimeout time.Duration
+		// PingRetries is how many times the first ping is retried before giving up.
+		PingRetries int
+		// PingBackoff is the wait before the first retry. It doubles after every retry.
+		PingBackoff time.Duration
 	}
 
 	Client interface {
 		Close() error
-		Connect(opts ConnectionOptions) *StorageClient
+		Connect(ctx context.Context, opts ConnectionOptions) (*StorageClient, error)
 		BeginTx(ctx context.Context, ops *sql.TxOptions) (*sql.Tx, error)
 		Query(ctx context.Context, sql string, args ...interface{}) (*sql.Rows, error)
 		QueryRow(ctx context.Context, sql string, args ...interface{}) *sql.Row
 		Exec(ctx context.Context, sql string, args ...interface{}) (sql.Result, error)
+		Ping(ctx context.Context) error
+		Stats() PoolStats
 	}
 )
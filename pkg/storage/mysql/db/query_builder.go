@@ -9,43 +9,83 @@ import (
 var (
 	SqlBuilderJoinMismatchLenErr    = errors.New("join statement should be create by equal number of tables")
 	SqlBuilderFromClauseErr         = errors.New("from clause should provide a valida table name")
-	SqlBuilderMissingActionErr      = errors.New("action should be select, update")
+	SqlBuilderMissingActionErr      = errors.New("action should be select, update, insert, delete")
 	SqlBuilderMissingOrderFieldsErr = errors.New("order by should provide a valid fields")
+	SqlBuilderInvalidOrderErr       = errors.New("order by direction should be asc or desc and nulls first or last")
+	SqlBuilderMissingGroupFieldsErr = errors.New("group by should provide at least one column")
+	SqlBuilderMissingColumnsErr     = errors.New("insert statement should provide at least one column")
+	SqlBuilderMissingValuesErr      = errors.New("insert statement should provide at least one row of values")
+	SqlBuilderMissingWhereErr       = errors.New("delete statement should provide a where clause or explicitly delete all rows")
+	SqlBuilderEmptyGroupErr         = errors.New("where group should provide at least one condition")
+	SqlBuilderDanglingGroupErr      = errors.New("where group should not end with and, or")
+	SqlBuilderMissingUnionErr       = errors.New("where group conditions should be joined by and, or")
+	SqlBuilderArgsMismatchErr       = errors.New("condition should bind as many values as its operator takes")
+	SqlBuilderValuesMismatchErr     = errors.New("insert statement should provide one value per column")
 )
 
 const (
-	And               Operator  = " AND "
-	Or                Operator  = " OR "
-	Equal             Operator  = " = "
-	GreaterThan       Operator  = " > "
-	LessThan          Operator  = " < "
-	GreaterEqualsThan Operator  = " >= "
-	LessEqualsThan    Operator  = " <= "
-	Asc               OrderType = " ASC "
-	Desc              OrderType = " DESC "
-
-	space           = " "
-	defaultMaxPages = 10
+	And               Operator   = " AND "
+	Or                Operator   = " OR "
+	Equal             Operator   = " = "
+	GreaterThan       Operator   = " > "
+	LessThan          Operator   = " < "
+	GreaterEqualsThan Operator   = " >= "
+	LessEqualsThan    Operator   = " <= "
+	NotEqual          Operator   = " <> "
+	In                Operator   = " IN "
+	NotIn             Operator   = " NOT IN "
+	Like              Operator   = " LIKE "
+	NotLike           Operator   = " NOT LIKE "
+	Between           Operator   = " BETWEEN "
+	IsNull            Operator   = " IS NULL"
+	IsNotNull         Operator   = " IS NOT NULL"
+	Asc               OrderType  = " ASC"
+	Desc              OrderType  = " DESC"
+	NullsFirst        NullsOrder = "first"
+	NullsLast         NullsOrder = "last"
+
+	DefaultMaxPages = 10
+
+	space = " "
 )
 
 type (
 	Table  string
 	Column string
 
-	Operator  string
-	OrderType string
+	Operator   string
+	OrderType  string
+	NullsOrder string
+
+	// Order sorts by a column. An empty Direction sorts ascending, and an empty Nulls keeps the MySQL
+	// default of nulls first when ascending and last when descending.
+	Order struct {
+		Column    Column
+		Direction OrderType
+		Nulls     NullsOrder
+	}
 
 	condition struct {
 		union string
 		key   string
 		op    Operator
+		args  []interface{}
+		// group holds the conditions rendered between parentheses in place of key and op.
+		group []condition
+	}
+
+	// Group collects the conditions of a parenthesised sub-expression. See WhereGroup.
+	Group struct {
+		conditions []condition
 	}
 
-	sort struct {
-		operator string
-		values   []string
+	groupWhere struct {
+		g *Group
 	}
 
+	// sort is nil until OrderBy is called, so an empty OrderBy can be told apart.
+	sort []Order
+
 	pagination struct {
 		limit  int
 		offset int
@@ -73,10 +113,15 @@ type (
 		withjoins   []tableInfo
 		sets        []condition
 		wheres      []condition
+		groups      []string
+		having      []condition
 		args        []interface{}
 		withcounter bool
 		sort        sort
 		pagination  pagination
+		inserts     []string
+		values      [][]interface{}
+		all         bool
 	}
 
 	Filters struct {
@@ -112,6 +157,18 @@ type (
 		q *query
 	}
 
+	beforeGroup struct {
+		q *query
+	}
+
+	beforeHaving struct {
+		q *query
+	}
+
+	beforeHavingConditional struct {
+		q *query
+	}
+
 	beforeConditional struct {
 		q *query
 	}
@@ -124,6 +181,18 @@ type (
 		q *query
 	}
 
+	beforeInsert struct {
+		q *query
+	}
+
+	beforeValues struct {
+		q *query
+	}
+
+	beforeDelete struct {
+		q *query
+	}
+
 	finish struct {
 		q *query
 	}
@@ -150,6 +219,40 @@ func Select(fields ...Column) *beforeSelect {
 	return &beforeSelect{q: q}
 }
 
+// Count projects COUNT(c). Pass "*" to count rows.
+func Count(c Column) Column {
+	return aggregate("COUNT", c)
+}
+
+// Sum projects SUM(c).
+func Sum(c Column) Column {
+	return aggregate("SUM", c)
+}
+
+// Avg projects AVG(c).
+func Avg(c Column) Column {
+	return aggregate("AVG", c)
+}
+
+// Min projects MIN(c).
+func Min(c Column) Column {
+	return aggregate("MIN", c)
+}
+
+// Max projects MAX(c).
+func Max(c Column) Column {
+	return aggregate("MAX", c)
+}
+
+func aggregate(fn string, c Column) Column {
+	return Column(fn + "(" + string(c) + ")")
+}
+
+// As names a projection, so it can be referred to by ORDER BY and HAVING.
+func (c Column) As(alias string) Column {
+	return Column(string(c) + " AS " + alias)
+}
+
 func (q *beforeSelect) From(t Table) *beforeFrom {
 	q.q.table.name = string(t)
 	return &beforeFrom{q: q.q}
@@ -191,59 +294,199 @@ func (q *beforeTable) Table(t Table, k Column) *beforeFrom {
 	return &beforeFrom{q: q.q}
 }
 
-func (q *beforeFrom) Where(c Column, o Operator) *beforeWhere {
-	if q.q.wheres == nil {
-		q.q.wheres = make([]condition, 0)
+func (q *beforeFrom) Where(c Column, o Operator, values ...interface{}) *beforeWhere {
+	return where(q.q, c, o, values)
+}
+
+func (q *beforeFrom) WhereGroup(fn func(g *Group)) *beforeWhere {
+	return whereGroup(q.q, fn)
+}
+
+func (q *beforeSet) Where(c Column, o Operator, values ...interface{}) *beforeWhere {
+	return where(q.q, c, o, values)
+}
+
+func (q *beforeSet) WhereGroup(fn func(g *Group)) *beforeWhere {
+	return whereGroup(q.q, fn)
+}
+
+func (q *beforeConditional) Where(c Column, o Operator, values ...interface{}) *beforeWhere {
+	return where(q.q, c, o, values)
+}
+
+func (q *beforeConditional) WhereGroup(fn func(g *Group)) *beforeWhere {
+	return whereGroup(q.q, fn)
+}
+
+func (q *beforeDelete) Where(c Column, o Operator, values ...interface{}) *beforeWhere {
+	return where(q.q, c, o, values)
+}
+
+func (q *beforeDelete) WhereGroup(fn func(g *Group)) *beforeWhere {
+	return whereGroup(q.q, fn)
+}
+
+// where appends a condition binding values. IN and NOT IN take any number of values, BETWEEN
+// takes two, IS NULL and IS NOT NULL none and every other operator one. An empty IN list matches
+// no row and an empty NOT IN list every row.
+func where(q *query, c Column, o Operator, values []interface{}) *beforeWhere {
+	if q.wheres == nil {
+		q.wheres = make([]condition, 0)
 	}
 
-	q.q.wheres = append(q.q.wheres, condition{
-		key: string(c),
-		op:  o,
+	q.wheres = append(q.wheres, condition{
+		key:  string(c),
+		op:   o,
+		args: values,
 	})
 
-	return &beforeWhere{q: q.q}
+	return &beforeWhere{q: q}
 }
 
-func (q *beforeSet) Where(c Column, o Operator) *beforeWhere {
-	if q.q.wheres == nil {
-		q.q.wheres = make([]condition, 0)
+// whereGroup appends the conditions added by fn as a single parenthesised condition.
+func whereGroup(q *query, fn func(g *Group)) *beforeWhere {
+	g := &Group{conditions: make([]condition, 0)}
+	fn(g)
+
+	if q.wheres == nil {
+		q.wheres = make([]condition, 0)
 	}
 
-	q.q.wheres = append(q.q.wheres, condition{
-		key: string(c),
-		op:  o,
+	q.wheres = append(q.wheres, condition{group: g.conditions})
+	return &beforeWhere{q: q}
+}
+
+func (g *Group) Where(c Column, o Operator, values ...interface{}) *groupWhere {
+	g.conditions = append(g.conditions, condition{
+		key:  string(c),
+		op:   o,
+		args: values,
 	})
+	return &groupWhere{g: g}
+}
+
+// WhereGroup nests another parenthesised sub-expression, to any depth.
+func (g *Group) WhereGroup(fn func(g *Group)) *groupWhere {
+	nested := &Group{conditions: make([]condition, 0)}
+	fn(nested)
 
-	return &beforeWhere{q: q.q}
+	g.conditions = append(g.conditions, condition{group: nested.conditions})
+	return &groupWhere{g: g}
 }
 
-func (q *beforeConditional) Where(c Column, o Operator) *beforeWhere {
-	if q.q.wheres == nil {
-		q.q.wheres = make([]condition, 0)
+func (w *groupWhere) And() *Group {
+	w.g.conditions[len(w.g.conditions)-1].union = string(And)
+	return w.g
+}
+
+func (w *groupWhere) Or() *Group {
+	w.g.conditions[len(w.g.conditions)-1].union = string(Or)
+	return w.g
+}
+
+// Ascending sorts by c in ascending order.
+func Ascending(c Column) Order {
+	return Order{Column: c, Direction: Asc}
+}
+
+// Descending sorts by c in descending order.
+func Descending(c Column) Order {
+	return Order{Column: c, Direction: Desc}
+}
+
+// NullsFirst returns the order with nulls sorted ahead of every value.
+func (o Order) NullsFirst() Order {
+	o.Nulls = NullsFirst
+	return o
+}
+
+// NullsLast returns the order with nulls sorted after every value.
+func (o Order) NullsLast() Order {
+	o.Nulls = NullsLast
+	return o
+}
+
+func (q *beforeFrom) OrderBy(orders ...Order) *beforeLimit {
+	return orderedBy(q.q, orders)
+}
+
+func (q *beforeWhere) OrderBy(orders ...Order) *beforeLimit {
+	return orderedBy(q.q, orders)
+}
+
+// orderedBy sorts by every order in turn, each one breaking the ties of the previous.
+func orderedBy(q *query, orders []Order) *beforeLimit {
+	q.sort = append(make([]Order, 0, len(orders)), orders...)
+	return &beforeLimit{q: q}
+}
+
+func (q *beforeFrom) GroupBy(columns ...Column) *beforeGroup {
+	return groupedBy(q.q, columns)
+}
+
+func (q *beforeWhere) GroupBy(columns ...Column) *beforeGroup {
+	return groupedBy(q.q, columns)
+}
+
+func groupedBy(q *query, columns []Column) *beforeGroup {
+	q.groups = make([]string, len(columns))
+	for i, c := range columns {
+		q.groups[i] = string(c)
 	}
+	return &beforeGroup{q: q}
+}
+
+// Having filters the groups. Its values are bound like the ones of Where.
+func (q *beforeGroup) Having(c Column, o Operator, values ...interface{}) *beforeHaving {
+	return having(q.q, c, o, values)
+}
 
-	q.q.wheres = append(q.q.wheres, condition{
-		key: string(c),
-		op:  o,
+func (q *beforeHavingConditional) Having(c Column, o Operator, values ...interface{}) *beforeHaving {
+	return having(q.q, c, o, values)
+}
+
+func having(q *query, c Column, o Operator, values []interface{}) *beforeHaving {
+	q.having = append(q.having, condition{
+		key:  string(c),
+		op:   o,
+		args: values,
 	})
+	return &beforeHaving{q: q}
+}
 
-	return &beforeWhere{q: q.q}
+func (q *beforeHaving) And() *beforeHavingConditional {
+	q.q.having[len(q.q.having)-1].union = string(And)
+	return &beforeHavingConditional{q: q.q}
 }
 
-func (q *beforeWhere) OrderBy(sort OrderType, columns ...Column) *beforeLimit {
-	fields := make([]string, len(columns))
-	for i, v := range columns {
-		fields[i] = string(v)
-	}
+func (q *beforeHaving) Or() *beforeHavingConditional {
+	q.q.having[len(q.q.having)-1].union = string(Or)
+	return &beforeHavingConditional{q: q.q}
+}
 
-	q.q.sort.operator = string(sort)
-	q.q.sort.values = fields
-	return &beforeLimit{q: q.q}
+func (q *beforeGroup) OrderBy(orders ...Order) *beforeLimit {
+	return orderedBy(q.q, orders)
+}
+
+func (q *beforeHaving) OrderBy(orders ...Order) *beforeLimit {
+	return orderedBy(q.q, orders)
+}
+
+func (q *beforeGroup) Limit(offset, limit int) *finish {
+	q.q.pagination.limit = limit
+	q.q.pagination.offset = offset
+	return &finish{q: q.q}
+}
+
+func (q *beforeHaving) Limit(offset, limit int) *finish {
+	q.q.pagination.limit = limit
+	q.q.pagination.offset = offset
+	return &finish{q: q.q}
 }
 
 func (q *beforeFrom) Limit(offset, limit int) *finish {
 	if limit == 0 {
-		limit = defaultMaxPages
+		limit = DefaultMaxPages
 	}
 
 	q.q.pagination.limit = limit
@@ -281,7 +524,7 @@ func Update(table Table) *beforeUpdate {
 	return &beforeUpdate{q: q}
 }
 
-func (q *beforeUpdate) Set(column Column, operator Operator) *beforeSet {
+func (q *beforeUpdate) Set(column Column, operator Operator, value interface{}) *beforeSet {
 	if q.q.sets == nil {
 		q.q.sets = make([]condition, 0)
 	}
@@ -290,11 +533,11 @@ func (q *beforeUpdate) Set(column Column, operator Operator) *beforeSet {
 		q.q.sets[len(q.q.sets)-1].union = " ,"
 	}
 
-	q.q.sets = append(q.q.sets, condition{key: string(column), op: operator})
+	q.q.sets = append(q.q.sets, condition{key: string(column), op: operator, args: []interface{}{value}})
 	return &beforeSet{q: q.q}
 }
 
-func (q *beforeSet) Set(column Column, operator Operator) *beforeSet {
+func (q *beforeSet) Set(column Column, operator Operator, value interface{}) *beforeSet {
 	if q.q.sets == nil {
 		q.q.sets = make([]condition, 0)
 	}
@@ -303,55 +546,132 @@ func (q *beforeSet) Set(column Column, operator Operator) *beforeSet {
 		q.q.sets[len(q.q.sets)-1].union = ", "
 	}
 
-	q.q.sets = append(q.q.sets, condition{key: string(column), op: operator})
+	q.q.sets = append(q.q.sets, condition{key: string(column), op: operator, args: []interface{}{value}})
 	return &beforeSet{q: q.q}
 }
 
-func (q *beforeFrom) Build() (string, error) {
-	statement, err := selectStmt(q.q)
-	return statement, err
+func Insert(table Table) *beforeInsert {
+	q := &query{}
+	q.action = "insert"
+	q.sql = "INSERT INTO " + string(table) + space
+	q.table.name = string(table)
+	return &beforeInsert{q: q}
+}
+
+func (q *beforeInsert) Columns(columns ...Column) *beforeValues {
+	fields := make([]string, len(columns))
+	for i, v := range columns {
+		fields[i] = string(v)
+	}
+
+	q.q.inserts = fields
+	return &beforeValues{q: q.q}
+}
+
+// Values appends a row holding one value per column. Call it once per row to insert.
+func (q *beforeValues) Values(values ...interface{}) *beforeValues {
+	q.q.values = append(q.q.values, values)
+	return q
+}
+
+func (q *beforeValues) Build() (string, []interface{}, error) {
+	return insertStmt(q.q)
 }
 
-func (q *finish) Build() (string, error) {
-	statement, err := selectStmt(q.q)
-	return statement, err
+func Delete(table Table) *beforeDelete {
+	q := &query{}
+	q.action = "delete"
+	q.sql = "DELETE FROM " + string(table)
+	q.table.name = string(table)
+	return &beforeDelete{q: q}
+}
+
+// All opts into deleting every row of the table, since Build refuses a delete without a where clause.
+func (q *beforeDelete) All() *beforeDelete {
+	q.q.all = true
+	return q
+}
+
+func (q *beforeDelete) Build() (string, []interface{}, error) {
+	return deleteStmt(q.q)
+}
+
+func (q *beforeFrom) Build() (string, []interface{}, error) {
+	return selectStmt(q.q)
 }
 
-func (q *beforeWhere) Build() (string, error) {
+func (q *finish) Build() (string, []interface{}, error) {
+	return selectStmt(q.q)
+}
+
+func (q *beforeLimit) Build() (string, []interface{}, error) {
+	return selectStmt(q.q)
+}
+
+func (q *beforeGroup) Build() (string, []interface{}, error) {
+	return selectStmt(q.q)
+}
+
+func (q *beforeHaving) Build() (string, []interface{}, error) {
+	return selectStmt(q.q)
+}
+
+func (q *beforeWhere) Build() (string, []interface{}, error) {
 	switch q.q.action {
 	case "select":
-		statement, err := selectStmt(q.q)
-		return statement, err
+		return selectStmt(q.q)
 	case "update":
-		statement, err := updateStmt(q.q)
-		return statement, err
+		return updateStmt(q.q)
+	case "delete":
+		return deleteStmt(q.q)
 	default:
-		return "", SqlBuilderMissingActionErr
+		return "", nil, SqlBuilderMissingActionErr
 	}
 }
 
-func selectStmt(q *query) (string, error) {
+func selectStmt(q *query) (string, []interface{}, error) {
 	if len(q.joins) != len(q.withjoins) {
-		return "", SqlBuilderJoinMismatchLenErr
+		return "", nil, SqlBuilderJoinMismatchLenErr
 	}
 
 	if len(q.table.name) == 0 {
-		return "", SqlBuilderFromClauseErr
+		return "", nil, SqlBuilderFromClauseErr
+	}
+
+	if err := checkOrder(q.sort); err != nil {
+		return "", nil, err
+	}
+
+	if q.groups != nil && len(q.groups) == 0 {
+		return "", nil, SqlBuilderMissingGroupFieldsErr
+	}
+
+	if err := check(q.wheres); err != nil {
+		return "", nil, err
 	}
 
-	if len(q.sort.operator) > 0 && len(q.sort.values) == 0 {
-		return "", SqlBuilderMissingOrderFieldsErr
+	if err := check(q.having); err != nil {
+		return "", nil, err
 	}
 
+	// The counter sub-query repeats the where and having clauses ahead of the outer ones, so its values come first.
+	q.args = make([]interface{}, 0)
+	if q.withcounter {
+		q.args = bind(bind(q.args, q.wheres), q.having)
+	}
+	q.args = bind(bind(q.args, q.wheres), q.having)
+
 	var sb strings.Builder
 	sb.WriteString(sel(q))
 	sb.WriteString(from(q.table.name))
 	sb.WriteString(join(q.joins, q.withjoins))
 	sb.WriteString(wheres(q.wheres))
+	sb.WriteString(groupBy(q.groups))
+	sb.WriteString(havings(q.having))
 	sb.WriteString(orderBy(q.sort))
 	sb.WriteString(pages(q.pagination))
 	sb.WriteString(";")
-	return sb.String(), nil
+	return sb.String(), q.args, nil
 }
 
 func sel(q *query) string {
@@ -361,13 +681,38 @@ func sel(q *query) string {
 
 	var sb strings.Builder
 	sb.WriteString("SELECT count(*)")
+	if len(q.groups) == 0 {
+		sb.WriteString(from(q.table.name))
+		sb.WriteString(join(q.joins, q.withjoins))
+		sb.WriteString(wheres(q.wheres))
+		return fmt.Sprintf("SELECT %s, (%s) as total", q.columns, sb.String())
+	}
+
+	// A grouped select counts its groups rather than the rows they gather.
+	sb.WriteString(" FROM (SELECT 1")
 	sb.WriteString(from(q.table.name))
 	sb.WriteString(join(q.joins, q.withjoins))
 	sb.WriteString(wheres(q.wheres))
-
+	sb.WriteString(groupBy(q.groups))
+	sb.WriteString(havings(q.having))
+	sb.WriteString(") AS grouped")
 	return fmt.Sprintf("SELECT %s, (%s) as total", q.columns, sb.String())
 }
 
+func groupBy(groups []string) string {
+	if len(groups) > 0 {
+		return " GROUP BY " + strings.Join(groups, ", ")
+	}
+	return ""
+}
+
+func havings(h []condition) string {
+	if len(h) > 0 {
+		return " HAVING " + expression(h)
+	}
+	return ""
+}
+
 func pages(p pagination) string {
 	if p.offset >= 0 && p.limit >= 0 && (p.offset > 0 || p.limit > 0) {
 		return fmt.Sprintf(" LIMIT %d, %d", p.offset, p.limit)
@@ -375,11 +720,50 @@ func pages(p pagination) string {
 	return ""
 }
 
-func orderBy(s sort) string {
-	if len(s.operator) > 0 {
-		return " ORDER BY " + strings.Join(s.values, ", ")
+// orderBy renders the sort. MySQL has no NULLS FIRST or NULLS LAST, so a column with an explicit
+// nulls order is preceded by a null check, which sorts false ahead of true.
+func orderBy(orders []Order) string {
+	if len(orders) == 0 {
+		return ""
 	}
-	return ""
+
+	terms := make([]string, 0, len(orders))
+	for _, o := range orders {
+		switch o.Nulls {
+		case NullsFirst:
+			terms = append(terms, string(o.Column)+string(IsNotNull))
+		case NullsLast:
+			terms = append(terms, string(o.Column)+string(IsNull))
+		}
+
+		direction := o.Direction
+		if len(direction) == 0 {
+			direction = Asc
+		}
+		terms = append(terms, string(o.Column)+string(direction))
+	}
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+func checkOrder(orders []Order) error {
+	if orders != nil && len(orders) == 0 {
+		return SqlBuilderMissingOrderFieldsErr
+	}
+
+	for _, o := range orders {
+		if len(o.Column) == 0 {
+			return SqlBuilderMissingOrderFieldsErr
+		}
+
+		if o.Direction != "" && o.Direction != Asc && o.Direction != Desc {
+			return SqlBuilderInvalidOrderErr
+		}
+
+		if o.Nulls != "" && o.Nulls != NullsFirst && o.Nulls != NullsLast {
+			return SqlBuilderInvalidOrderErr
+		}
+	}
+	return nil
 }
 
 func from(name string) string {
@@ -387,16 +771,109 @@ func from(name string) string {
 }
 
 func wheres(w []condition) string {
-	out := ""
 	if len(w) > 0 {
-		for _, where := range w {
-			out += where.key + string(where.op) + "?" + where.union
-		}
-		out = " WHERE " + out
+		return " WHERE " + expression(w)
+	}
+	return ""
+}
+
+// expression renders conditions joined by their unions, groups between parentheses.
+func expression(w []condition) string {
+	out := ""
+	for _, where := range w {
+		out += predicate(where) + where.union
 	}
 	return out
 }
 
+// bind appends the values of the conditions in the order their placeholders are rendered.
+func bind(args []interface{}, w []condition) []interface{} {
+	for _, c := range w {
+		if c.group != nil {
+			args = bind(args, c.group)
+			continue
+		}
+		args = append(args, c.args...)
+	}
+	return args
+}
+
+// check rejects empty groups, groups whose last condition is followed by and, or, groups whose other
+// conditions are not, and conditions binding a number of values their operator does not take.
+func check(w []condition) error {
+	for _, c := range w {
+		if c.group == nil {
+			if !arity(c) {
+				return SqlBuilderArgsMismatchErr
+			}
+			continue
+		}
+
+		if len(c.group) == 0 {
+			return SqlBuilderEmptyGroupErr
+		}
+
+		if len(c.group[len(c.group)-1].union) > 0 {
+			return SqlBuilderDanglingGroupErr
+		}
+
+		for _, g := range c.group[:len(c.group)-1] {
+			if len(g.union) == 0 {
+				return SqlBuilderMissingUnionErr
+			}
+		}
+
+		if err := check(c.group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arity reports whether the condition binds as many values as its operator takes.
+func arity(c condition) bool {
+	switch c.op {
+	case IsNull, IsNotNull:
+		return len(c.args) == 0
+	case Between:
+		return len(c.args) == 2
+	case In, NotIn:
+		return true
+	default:
+		return len(c.args) == 1
+	}
+}
+
+// predicate renders a condition with the placeholders its operator binds.
+func predicate(c condition) string {
+	if c.group != nil {
+		return "(" + expression(c.group) + ")"
+	}
+
+	switch c.op {
+	case IsNull, IsNotNull:
+		return c.key + string(c.op)
+	case Between:
+		return c.key + string(c.op) + "? AND ?"
+	case In, NotIn:
+		// MySQL rejects an empty list, so render the predicate it would stand for.
+		if len(c.args) == 0 {
+			if c.op == In {
+				return "1 = 0"
+			}
+			return "1 = 1"
+		}
+		return c.key + string(c.op) + placeholders(len(c.args))
+	default:
+		return c.key + string(c.op) + "?"
+	}
+}
+
+// placeholders renders a parenthesised list of n placeholders.
+func placeholders(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
 func join(joins []tableInfo, withjoins []tableInfo) string {
 	out := ""
 	if len(joins) > 0 {
@@ -413,23 +890,78 @@ func join(joins []tableInfo, withjoins []tableInfo) string {
 	return out
 }
 
-func updateStmt(q *query) (string, error) {
+func updateStmt(q *query) (string, []interface{}, error) {
+	if err := check(q.wheres); err != nil {
+		return "", nil, err
+	}
+
+	statement := q.sql
 	if len(q.sets) > 0 {
 		sets := ""
 		for _, set := range q.sets {
 			sets += set.key + string(set.op) + "?" + set.union
 		}
-		q.sql += "SET " + sets
+		statement += "SET " + sets
 	}
 
-	if len(q.wheres) > 0 {
-		wheres := ""
-		for _, where := range q.wheres {
-			wheres += where.key + string(where.op) + "?" + where.union
+	q.args = bind(make([]interface{}, 0), q.sets)
+	q.args = bind(q.args, q.wheres)
+
+	statement += wheres(q.wheres)
+	return strings.TrimSuffix(statement, space) + ";", q.args, nil
+}
+
+func insertStmt(q *query) (string, []interface{}, error) {
+	if len(q.table.name) == 0 {
+		return "", nil, SqlBuilderFromClauseErr
+	}
+
+	if len(q.inserts) == 0 {
+		return "", nil, SqlBuilderMissingColumnsErr
+	}
+
+	if len(q.values) == 0 {
+		return "", nil, SqlBuilderMissingValuesErr
+	}
+
+	q.args = make([]interface{}, 0, len(q.values)*len(q.inserts))
+	rows := make([]string, len(q.values))
+	for i, values := range q.values {
+		if len(values) != len(q.inserts) {
+			return "", nil, SqlBuilderValuesMismatchErr
 		}
-		q.sql += " WHERE " + wheres
+
+		rows[i] = placeholders(len(q.inserts))
+		q.args = append(q.args, values...)
 	}
 
-	q.sql = strings.TrimSuffix(q.sql, space) + ";"
-	return q.sql, nil
+	var sb strings.Builder
+	sb.WriteString(q.sql)
+	sb.WriteString("(" + strings.Join(q.inserts, ", ") + ")")
+	sb.WriteString(" VALUES ")
+	sb.WriteString(strings.Join(rows, ", "))
+	sb.WriteString(";")
+	return sb.String(), q.args, nil
+}
+
+func deleteStmt(q *query) (string, []interface{}, error) {
+	if len(q.table.name) == 0 {
+		return "", nil, SqlBuilderFromClauseErr
+	}
+
+	if len(q.wheres) == 0 && !q.all {
+		return "", nil, SqlBuilderMissingWhereErr
+	}
+
+	if err := check(q.wheres); err != nil {
+		return "", nil, err
+	}
+
+	q.args = bind(make([]interface{}, 0), q.wheres)
+
+	var sb strings.Builder
+	sb.WriteString(q.sql)
+	sb.WriteString(wheres(q.wheres))
+	sb.WriteString(";")
+	return sb.String(), q.args, nil
 }
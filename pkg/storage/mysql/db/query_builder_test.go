@@ -11,17 +11,18 @@ func TestQuery_BuildSelectAll(t *testing.T) {
 		users Table = "users"
 	)
 
-	q, err := Select().
+	q, args, err := Select().
 		From(users).
-		Where("name", Equal).
+		Where("name", Equal, "ana").
 		And().
-		Where("age", Equal).
+		Where("age", Equal, 30).
 		Build()
 
 	expected := `SELECT * FROM users WHERE name = ? AND age = ?;`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30}, args)
 }
 
 func TestQuery_BuildSelectWithCounter(t *testing.T) {
@@ -29,18 +30,19 @@ func TestQuery_BuildSelectWithCounter(t *testing.T) {
 		users Table = "users"
 	)
 
-	q, err := Select().
+	q, args, err := Select().
 		WithCounter().
 		From(users).
-		Where("name", Equal).
+		Where("name", Equal, "ana").
 		And().
-		Where("age", Equal).
+		Where("age", Equal, 30).
 		Build()
 
 	expected := `SELECT *, (SELECT count(*) FROM users WHERE name = ? AND age = ?) as total FROM users WHERE name = ? AND age = ?;`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30, "ana", 30}, args)
 }
 
 func TestQuery_BuildSelect(t *testing.T) {
@@ -49,17 +51,30 @@ func TestQuery_BuildSelect(t *testing.T) {
 		users   Table = "users"
 	)
 
-	q, err := Select(columns...).
+	q, args, err := Select(columns...).
 		From(users).
-		Where("name", Equal).
+		Where("name", Equal, "ana").
 		And().
-		Where("age", Equal).
+		Where("age", Equal, 30).
 		Build()
 
 	expected := `SELECT id, name, age FROM users WHERE name = ? AND age = ?;`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30}, args)
+}
+
+func TestQuery_BuildSelectWithoutWhere(t *testing.T) {
+	var (
+		users Table = "users"
+	)
+
+	q, args, err := Select().From(users).Build()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM users;`, q)
+	assert.Empty(t, args)
 }
 
 func TestQuery_BuildJoin(t *testing.T) {
@@ -70,21 +85,22 @@ func TestQuery_BuildJoin(t *testing.T) {
 		history     Table = "history"
 	)
 
-	q, err := Select(columns...).
+	q, args, err := Select(columns...).
 		From(users).
 		Join(users, "id").
 		Table(credentials, "users_id").
 		Join(users, "id").
 		Table(history, "users_id").
-		Where("id", Equal).
+		Where("id", Equal, 1).
 		And().
-		Where("name", Equal).
+		Where("name", Equal, "ana").
 		Build()
 
 	expected := `SELECT id, name, age FROM users JOIN credentials ON credentials.users_id = users.id JOIN history ON history.users_id = users.id WHERE id = ? AND name = ?;`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{1, "ana"}, args)
 }
 
 func TestQuery_BuildUpdate(t *testing.T) {
@@ -95,36 +111,463 @@ func TestQuery_BuildUpdate(t *testing.T) {
 		age   Column = "age"
 	)
 
-	q, err := Update(users).
-		Set(name, Equal).
-		Set(age, Equal).
-		Where(id, Equal).Or().
-		Where(age, GreaterThan).Build()
+	q, args, err := Update(users).
+		Set(name, Equal, "ana").
+		Set(age, Equal, 31).
+		Where(id, Equal, 1).Or().
+		Where(age, GreaterThan, 90).Build()
 
 	expected := `UPDATE users SET name = ?, age = ? WHERE id = ? OR age > ?;`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 31, 1, 90}, args)
+}
+
+func TestQuery_BuildTwice(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		age   Column = "age"
+	)
+
+	b := Update(users).Set(age, Equal, 31).Where(id, Equal, 1)
+
+	first, firstArgs, err := b.Build()
+	assert.Nil(t, err)
+
+	second, secondArgs, err := b.Build()
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstArgs, secondArgs)
+}
+
+func TestQuery_BuildOperators(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Select().
+		From(users).
+		Where(id, In, 1, 2, 3).And().
+		Where(name, NotLike, "a%").And().
+		Where(age, Between, 20, 40).And().
+		Where(name, IsNotNull).And().
+		Where(age, NotEqual, 30).
+		Build()
+
+	expected := `SELECT * FROM users WHERE id IN (?, ?, ?) AND name NOT LIKE ? AND age BETWEEN ? AND ? AND name IS NOT NULL AND age <> ?;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{1, 2, 3, "a%", 20, 40, 30}, args)
+
+	q, args, err = Update(users).
+		Set(name, Equal, "ana").
+		Where(id, NotIn, 1, 2).Or().
+		Where(name, IsNull).
+		Build()
+
+	expected = `UPDATE users SET name = ? WHERE id NOT IN (?, ?) OR name IS NULL;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 1, 2}, args)
+
+	q, args, err = Delete(users).Where(name, Like, "a_a").Build()
+
+	expected = `DELETE FROM users WHERE name LIKE ?;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"a_a"}, args)
+}
+
+func TestQuery_BuildEmptyIn(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+	)
+
+	q, args, err := Select().From(users).Where(id, In).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM users WHERE 1 = 0;`, q)
+	assert.Empty(t, args)
+
+	q, args, err = Select().From(users).Where(id, NotIn).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM users WHERE 1 = 1;`, q)
+	assert.Empty(t, args)
+}
+
+func TestQuery_BuildArgsMismatch(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		age   Column = "age"
+	)
+
+	_, _, err := Select().From(users).Where(id, Equal).Build()
+	assert.Equal(t, SqlBuilderArgsMismatchErr, err)
+
+	_, _, err = Select().From(users).Where(age, Between, 1).Build()
+	assert.Equal(t, SqlBuilderArgsMismatchErr, err)
+
+	_, _, err = Delete(users).Where(id, IsNull, 1).Build()
+	assert.Equal(t, SqlBuilderArgsMismatchErr, err)
+
+	_, _, err = Update(users).Set(age, Equal, 1).WhereGroup(func(g *Group) {
+		g.Where(id, Equal, 1, 2)
+	}).Build()
+	assert.Equal(t, SqlBuilderArgsMismatchErr, err)
+}
+
+func TestQuery_BuildWhereGroup(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Select().
+		WithCounter().
+		From(users).
+		WhereGroup(func(g *Group) {
+			g.Where(name, Like, "a%").Or().Where(name, IsNull)
+		}).And().
+		Where(age, GreaterThan, 18).
+		Build()
+
+	expected := `SELECT *, (SELECT count(*) FROM users WHERE (name LIKE ? OR name IS NULL) AND age > ?) as total FROM users WHERE (name LIKE ? OR name IS NULL) AND age > ?;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"a%", 18, "a%", 18}, args)
+
+	q, args, err = Update(users).
+		Set(age, Equal, 31).
+		WhereGroup(func(g *Group) {
+			g.Where(id, In, 1, 2).Or().Where(age, LessThan, 18)
+		}).
+		Build()
+
+	expected = `UPDATE users SET age = ? WHERE (id IN (?, ?) OR age < ?);`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{31, 1, 2, 18}, args)
+}
+
+func TestQuery_BuildNestedWhereGroup(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Delete(users).
+		Where(id, GreaterThan, 1).And().
+		WhereGroup(func(g *Group) {
+			g.Where(name, Equal, "ana").Or().
+				WhereGroup(func(g *Group) {
+					g.Where(age, Between, 20, 30).And().
+						WhereGroup(func(g *Group) {
+							g.Where(name, NotLike, "b%").Or().Where(id, Equal, 7)
+						})
+				})
+		}).
+		Build()
+
+	expected := `DELETE FROM users WHERE id > ? AND (name = ? OR (age BETWEEN ? AND ? AND (name NOT LIKE ? OR id = ?)));`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{1, "ana", 20, 30, "b%", 7}, args)
+}
+
+func TestQuery_BuildWhereGroupErrors(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+	)
+
+	_, _, err := Select().From(users).WhereGroup(func(g *Group) {}).Build()
+	assert.Equal(t, SqlBuilderEmptyGroupErr, err)
+
+	_, _, err = Delete(users).WhereGroup(func(g *Group) {
+		g.Where(id, Equal, 1).And().WhereGroup(func(g *Group) {})
+	}).Build()
+	assert.Equal(t, SqlBuilderEmptyGroupErr, err)
+
+	_, _, err = Update(users).Set(id, Equal, 1).WhereGroup(func(g *Group) {
+		g.Where(id, Equal, 1).Or()
+	}).Build()
+	assert.Equal(t, SqlBuilderDanglingGroupErr, err)
+
+	_, _, err = Select().From(users).WhereGroup(func(g *Group) {
+		g.Where(id, Equal, 1)
+		g.Where(id, Equal, 2)
+	}).Build()
+	assert.Equal(t, SqlBuilderMissingUnionErr, err)
+
+	_, _, err = Delete(users).WhereGroup(func(g *Group) {
+		g.Where(id, Equal, 1).Or().WhereGroup(func(g *Group) {
+			g.Where(id, Equal, 2)
+			g.WhereGroup(func(g *Group) { g.Where(id, Equal, 3) })
+		})
+	}).Build()
+	assert.Equal(t, SqlBuilderMissingUnionErr, err)
 }
 
 func TestQuery_BuildSelectWithOrderAndLimit(t *testing.T) {
 	var (
-		sort          = []Column{"id", "name"}
 		columns       = []Column{"id", "name", "age"}
 		users   Table = "users"
 	)
 
-	q, err := Select(columns...).
+	q, args, err := Select(columns...).
 		From(users).
-		Where("name", Equal).
+		Where("name", Equal, "ana").
 		And().
-		Where("age", Equal).
-		OrderBy(Asc, sort...).
+		Where("age", Equal, 30).
+		OrderBy(Ascending("id"), Descending("name")).
 		Limit(0, 10).
 		Build()
 
-	expected := `SELECT id, name, age FROM users WHERE name = ? AND age = ? ORDER BY id, name LIMIT 0, 10;`
+	expected := `SELECT id, name, age FROM users WHERE name = ? AND age = ? ORDER BY id ASC, name DESC LIMIT 0, 10;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30}, args)
+}
+
+func TestQuery_BuildOrderBy(t *testing.T) {
+	var (
+		users Table  = "users"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, _, err := Select().
+		From(users).
+		OrderBy(Descending(age).NullsFirst(), Ascending(name).NullsLast(), Order{Column: "id"}).
+		Build()
+
+	expected := `SELECT * FROM users ORDER BY age IS NOT NULL, age DESC, name IS NULL, name ASC, id ASC;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+}
+
+func TestQuery_BuildOrderByErrors(t *testing.T) {
+	var (
+		users Table  = "users"
+		age   Column = "age"
+	)
+
+	_, _, err := Select().From(users).OrderBy().Build()
+	assert.Equal(t, SqlBuilderMissingOrderFieldsErr, err)
+
+	_, _, err = Select().From(users).OrderBy(Order{Direction: Asc}).Build()
+	assert.Equal(t, SqlBuilderMissingOrderFieldsErr, err)
+
+	_, _, err = Select().From(users).Where(age, Equal, 1).OrderBy(Order{Column: age, Direction: "; DROP TABLE users"}).Build()
+	assert.Equal(t, SqlBuilderInvalidOrderErr, err)
+
+	_, _, err = Select().From(users).OrderBy(Order{Column: age, Nulls: "middle"}).Build()
+	assert.Equal(t, SqlBuilderInvalidOrderErr, err)
+}
+
+func TestQuery_BuildAggregates(t *testing.T) {
+	var (
+		users Table  = "users"
+		age   Column = "age"
+		name  Column = "name"
+	)
+
+	q, args, err := Select(age, Count("*").As("total"), Sum(age), Avg(age).As("average"), Min(name), Max(name)).
+		From(users).
+		Build()
+
+	expected := `SELECT age, COUNT(*) AS total, SUM(age), AVG(age) AS average, MIN(name), MAX(name) FROM users;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Empty(t, args)
+}
+
+func TestQuery_BuildGroupByHaving(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Select(age, name, Count(id).As("total")).
+		From(users).
+		Where(age, GreaterThan, 18).
+		GroupBy(age, name).
+		Having(Count(id), GreaterEqualsThan, 2).And().
+		Having(Max(id), In, 7, 8).
+		OrderBy(Descending("total")).
+		Limit(0, 5).
+		Build()
+
+	expected := `SELECT age, name, COUNT(id) AS total FROM users WHERE age > ? GROUP BY age, name HAVING COUNT(id) >= ? AND MAX(id) IN (?, ?) ORDER BY total DESC LIMIT 0, 5;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{18, 2, 7, 8}, args)
+
+	q, args, err = Select(age).From(users).GroupBy(age).Build()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT age FROM users GROUP BY age;`, q)
+	assert.Empty(t, args)
+}
+
+func TestQuery_BuildGroupByWithCounter(t *testing.T) {
+	var (
+		users Table  = "users"
+		age   Column = "age"
+	)
+
+	q, args, err := Select(age, Count("*")).
+		WithCounter().
+		From(users).
+		Where(age, LessThan, 90).
+		GroupBy(age).
+		Having(Count("*"), GreaterThan, 1).
+		Build()
+
+	expected := `SELECT age, COUNT(*), (SELECT count(*) FROM (SELECT 1 FROM users WHERE age < ? GROUP BY age HAVING COUNT(*) > ?) AS grouped) as total FROM users WHERE age < ? GROUP BY age HAVING COUNT(*) > ?;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{90, 1, 90, 1}, args)
+}
+
+func TestQuery_BuildGroupByErrors(t *testing.T) {
+	var (
+		users Table  = "users"
+		age   Column = "age"
+	)
+
+	_, _, err := Select(age).From(users).GroupBy().Build()
+	assert.Equal(t, SqlBuilderMissingGroupFieldsErr, err)
+
+	_, _, err = Select(age).From(users).GroupBy(age).Having(Count("*"), Between, 1).Build()
+	assert.Equal(t, SqlBuilderArgsMismatchErr, err)
+}
+
+func TestQuery_BuildInsert(t *testing.T) {
+	var (
+		users Table  = "users"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Insert(users).
+		Columns(name, age).
+		Values("ana", 30).
+		Build()
+
+	expected := `INSERT INTO users (name, age) VALUES (?, ?);`
 
 	assert.Nil(t, err)
 	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30}, args)
+}
+
+func TestQuery_BuildInsertMultipleRows(t *testing.T) {
+	var (
+		users Table  = "users"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	q, args, err := Insert(users).
+		Columns(name, age).
+		Values("ana", 30).
+		Values("bob", 40).
+		Values("carla", 50).
+		Build()
+
+	expected := `INSERT INTO users (name, age) VALUES (?, ?), (?, ?), (?, ?);`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{"ana", 30, "bob", 40, "carla", 50}, args)
+}
+
+func TestQuery_BuildInsertErrors(t *testing.T) {
+	var (
+		users Table  = "users"
+		name  Column = "name"
+		age   Column = "age"
+	)
+
+	_, _, err := Insert("").Columns(name).Values("ana").Build()
+	assert.Equal(t, SqlBuilderFromClauseErr, err)
+
+	_, _, err = Insert(users).Columns().Values().Build()
+	assert.Equal(t, SqlBuilderMissingColumnsErr, err)
+
+	_, _, err = Insert(users).Columns(name).Build()
+	assert.Equal(t, SqlBuilderMissingValuesErr, err)
+
+	_, _, err = Insert(users).Columns(name, age).Values("ana", 30).Values("bob").Build()
+	assert.Equal(t, SqlBuilderValuesMismatchErr, err)
+}
+
+func TestQuery_BuildDelete(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+		age   Column = "age"
+	)
+
+	q, args, err := Delete(users).
+		Where(id, Equal, 1).Or().
+		Where(age, LessThan, 18).
+		Build()
+
+	expected := `DELETE FROM users WHERE id = ? OR age < ?;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Equal(t, []interface{}{1, 18}, args)
+}
+
+func TestQuery_BuildDeleteAll(t *testing.T) {
+	var (
+		users Table = "users"
+	)
+
+	q, args, err := Delete(users).All().Build()
+
+	expected := `DELETE FROM users;`
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, q)
+	assert.Empty(t, args)
+}
+
+func TestQuery_BuildDeleteErrors(t *testing.T) {
+	var (
+		users Table  = "users"
+		id    Column = "id"
+	)
+
+	_, _, err := Delete(users).Build()
+	assert.Equal(t, SqlBuilderMissingWhereErr, err)
+
+	_, _, err = Delete("").Where(id, Equal, 1).Build()
+	assert.Equal(t, SqlBuilderFromClauseErr, err)
 }
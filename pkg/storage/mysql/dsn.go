@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	charsetParam = "charset"
+	tlsPrefix    = "go-dao-pattern-"
+)
+
+var (
+	TLSInvalidCAErr  = errors.New("mysql tls ca file has no valid certificates")
+	TLSMissingKeyErr = errors.New("mysql tls client certificate and key should be set together")
+
+	tlsConfigs uint64
+)
+
+// TLSOptions enables TLS. Files are PEM encoded.
+type TLSOptions struct {
+	// CAFile holds the certificates that sign the server certificate. Empty uses the system pool.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate, for servers that require one.
+	CertFile string
+	KeyFile  string
+	// ServerName is verified against the server certificate. Defaults to Host.
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// config translates the options into the driver configuration, which takes care of escaping.
+func (o ConnectionOptions) config() (*mysql.Config, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = o.User
+	cfg.Passwd = o.Password
+	cfg.DBName = o.Schema
+	cfg.ParseTime = true
+	cfg.Timeout = o.DialTimeout
+	cfg.ReadTimeout = o.ReadTimeout
+	cfg.WriteTimeout = o.WriteTimeout
+
+	cfg.Net = "tcp"
+	if len(o.Socket) > 0 {
+		cfg.Net = "unix"
+	}
+	cfg.Addr = o.address()
+
+	if o.Loc != nil {
+		cfg.Loc = o.Loc
+	}
+
+	if len(o.Collation) > 0 {
+		cfg.Collation = o.Collation
+	}
+
+	cfg.Params = make(map[string]string, len(o.Params)+1)
+	for k, v := range o.Params {
+		cfg.Params[k] = v
+	}
+
+	if len(o.Charset) > 0 {
+		cfg.Params[charsetParam] = o.Charset
+	}
+
+	if o.TLS != nil {
+		t, err := o.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+
+		// The driver looks TLS configurations up by name, so every connection registers its own.
+		name := tlsPrefix + strconv.FormatUint(atomic.AddUint64(&tlsConfigs, 1), 10)
+		if err := mysql.RegisterTLSConfig(name, t); err != nil {
+			return nil, err
+		}
+		cfg.TLSConfig = name
+	}
+	return cfg, nil
+}
+
+func (o ConnectionOptions) address() string {
+	if len(o.Socket) > 0 {
+		return o.Socket
+	}
+	return net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+}
+
+func (t *TLSOptions) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if len(t.CAFile) > 0 {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, TLSInvalidCAErr
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(t.CertFile) > 0 || len(t.KeyFile) > 0 {
+		if len(t.CertFile) == 0 || len(t.KeyFile) == 0 {
+			return nil, TLSMissingKeyErr
+		}
+
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certificate writes a self-signed certificate and its key, and returns their paths.
+func certificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysql"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func TestConnectionOptions_ConfigDefaults(t *testing.T) {
+	opts := ConnectionOptions{Host: "db.local", Port: 3306, User: "root", Password: "p@ss/w:rd?", Schema: "company"}
+
+	cfg, err := opts.config()
+	require.Nil(t, err)
+	assert.Equal(t, "tcp", cfg.Net)
+	assert.Equal(t, "db.local:3306", cfg.Addr)
+	assert.True(t, cfg.ParseTime)
+	assert.Equal(t, time.UTC, cfg.Loc)
+	assert.Equal(t, "utf8mb4_general_ci", cfg.Collation)
+	assert.Empty(t, cfg.TLSConfig)
+	assert.Empty(t, cfg.Params)
+
+	// Special characters in the password survive the DSN.
+	parsed, err := mysql.ParseDSN(cfg.FormatDSN())
+	require.Nil(t, err)
+	assert.Equal(t, opts.Password, parsed.Passwd)
+	assert.Equal(t, opts.Schema, parsed.DBName)
+}
+
+func TestConnectionOptions_Config(t *testing.T) {
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	opts := ConnectionOptions{
+		Socket:       "/var/run/mysqld/mysqld.sock",
+		Schema:       "company",
+		Charset:      "utf8mb4",
+		Collation:    "utf8mb4_unicode_ci",
+		Loc:          loc,
+		DialTimeout:  time.Second,
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: 3 * time.Second,
+		Params:       map[string]string{"sql_mode": "'STRICT_ALL_TABLES'"},
+	}
+
+	cfg, err := opts.config()
+	require.Nil(t, err)
+	assert.Equal(t, "unix", cfg.Net)
+	assert.Equal(t, opts.Socket, cfg.Addr)
+	assert.Equal(t, "utf8mb4_unicode_ci", cfg.Collation)
+	assert.Equal(t, loc, cfg.Loc)
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, 2*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, 3*time.Second, cfg.WriteTimeout)
+	assert.Equal(t, map[string]string{"charset": "utf8mb4", "sql_mode": "'STRICT_ALL_TABLES'"}, cfg.Params)
+
+	// The caller's params are not modified.
+	assert.Len(t, opts.Params, 1)
+}
+
+func TestConnectionOptions_ConfigTLS(t *testing.T) {
+	certFile, keyFile := certificate(t)
+
+	opts := ConnectionOptions{Host: "db.local", Port: 3306, TLS: &TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "mysql"}}
+	cfg, err := opts.config()
+	require.Nil(t, err)
+	assert.NotEmpty(t, cfg.TLSConfig)
+	defer mysql.DeregisterTLSConfig(cfg.TLSConfig)
+
+	tlsCfg, err := opts.TLS.config()
+	require.Nil(t, err)
+	assert.NotNil(t, tlsCfg.RootCAs)
+	assert.Len(t, tlsCfg.Certificates, 1)
+	assert.Equal(t, "mysql", tlsCfg.ServerName)
+
+	// The driver resolves the registered configuration by name.
+	_, err = mysql.NewConnector(cfg)
+	assert.Nil(t, err)
+
+	opts.TLS = &TLSOptions{CAFile: keyFile}
+	_, err = opts.config()
+	assert.Equal(t, TLSInvalidCAErr, err)
+
+	opts.TLS = &TLSOptions{CertFile: certFile}
+	_, err = opts.config()
+	assert.Equal(t, TLSMissingKeyErr, err)
+
+	opts.TLS = &TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+	_, err = opts.config()
+	assert.True(t, os.IsNotExist(err))
+}
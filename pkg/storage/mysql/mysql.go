@@ -3,81 +3,230 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	oops "go-dao-pattern/pkg/errors"
+	"sync"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-const driver = "mysql"
+const (
+	duplicateEntryErrNumber = 1062
+)
 
+// StorageClient sends writes and transactions to the primary. Reads go to the replicas, when
+// configured, unless the context was marked WithPrimary or no replica is healthy.
 type StorageClient struct {
-	db *sql.DB
+	db       *sql.DB
+	address  string
+	stmts    *stmtCache
+	replicas *replicas
+	done     chan struct{}
+	closer   sync.Once
 }
 
-func InitConnection(opts ConnectionOptions) *StorageClient {
+// Ensure type implements interface.
+var _ Client = (*StorageClient)(nil)
+
+// InitConnection opens a connection pool and checks the server is reachable.
+// See Connect for how ctx and the retry options are honoured.
+func InitConnection(ctx context.Context, opts ConnectionOptions) (*StorageClient, error) {
 	client := new(StorageClient)
-	return client.Connect(opts)
+	return client.Connect(ctx, opts)
 }
 
 func NewStorageClient(db *sql.DB) StorageClient {
 	return StorageClient{
-		db: db,
+		db:    db,
+		stmts: newStmtCache(db, 0, ""),
+		done:  make(chan struct{}),
+	}
+}
+
+// Connect establishes a connection with remote server. The first ping is retried PingRetries times,
+// waiting PingBackoff before the first retry and doubling it after each one. The whole setup is bounded
+// by ctx and by ConnectTimeout, when set. An unreachable server yields an E5xxUNAVAILABLE error.
+// Unreachable replicas do not fail the connection; they are left out of rotation until they answer.
+func (c *StorageClient) Connect(ctx context.Context, opts ConnectionOptions) (*StorageClient, error) {
+	db, err := open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.ConnectTimeout)
+		defer cancel()
+	}
+
+	if err := ping(ctx, db, opts); err != nil {
+		db.Close()
+		return nil, err
 	}
+
+	client := &StorageClient{
+		db:      db,
+		address: opts.address(),
+		stmts:   newStmtCache(db, opts.StmtCacheSize, opts.address()),
+		done:    make(chan struct{}),
+	}
+
+	if len(opts.Replicas) > 0 {
+		rs, err := openReplicas(ctx, opts)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		client.replicas = rs
+	}
+
+	if opts.StatsInterval > 0 {
+		go client.report(opts.RegisterName, opts.StatsInterval)
+	}
+	return client, nil
 }
 
-// Connect establishes a connection with remote server.
-func (c *StorageClient) Connect(opts ConnectionOptions) *StorageClient {
-	chain := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=true", opts.User, opts.Password, opts.Host, opts.Port, opts.Schema)
+func open(opts ConnectionOptions) (*sql.DB, error) {
+	cfg, err := opts.config()
+	if err != nil {
+		return nil, invalid(err)
+	}
 
-	db, err := sql.Open(driver, chain)
+	// The connector keeps a copy of the TLS configuration, so the registered one is no longer needed.
+	connector, err := mysql.NewConnector(cfg)
+	if opts.TLS != nil {
+		mysql.DeregisterTLSConfig(cfg.TLSConfig)
+	}
 	if err != nil {
-		panic(err)
+		return nil, invalid(err)
 	}
+
+	db := sql.OpenDB(connector)
 	db.SetMaxOpenConns(opts.ConnMaxOpen)
 	db.SetMaxIdleConns(opts.ConnMaxIdle)
 	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	return db, nil
+}
+
+func ping(ctx context.Context, db *sql.DB, opts ConnectionOptions) error {
+	backoff := opts.PingBackoff
+	for attempt := 0; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
 
-	if err := db.Ping(); err != nil {
-		panic(err.Error())
+		if attempt >= opts.PingRetries {
+			return unavailable(opts.address(), attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return unavailable(opts.address(), attempt+1, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
 	}
+}
+
+func invalid(err error) error {
+	return oops.Errorf(oops.E5xxINTERNAL, "invalid mysql connection options [error: %s]", err.Error())
+}
 
-	return &StorageClient{db: db}
+func unavailable(address string, attempts int, err error) error {
+	return oops.Errorf(oops.E5xxUNAVAILABLE, "mysql unreachable [address: %s] [attempts: %d] [error: %s]", address, attempts, err.Error())
 }
 
-// Close closes the connection with remote server.
+// Close stops the stats reporter and closes the connection with remote server.
 func (c *StorageClient) Close() error {
-	if c.db != nil {
-		return c.db.Close()
-	}
-	return nil
+	var err error
+	c.closer.Do(func() {
+		if c.done != nil {
+			close(c.done)
+		}
+
+		if c.replicas != nil {
+			err = c.replicas.close()
+		}
+
+		if c.stmts != nil {
+			c.stmts.close()
+		}
+
+		if c.db != nil {
+			if e := c.db.Close(); e != nil {
+				err = e
+			}
+		}
+	})
+	return err
 }
 
-// Exec prepares a SQL query and executes it. Usually used for modification.
+// Exec executes a cached prepared statement. Usually used for modification.
 func (c *StorageClient) Exec(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
-	stmt, err := c.db.Prepare(sql)
-	if err != nil {
-		return nil, err
-	}
-	defer stmt.Close()
-	return stmt.ExecContext(ctx, args...)
+	return c.stmts.exec(ctx, sql, args...)
 }
 
-// Query prepares a SQL query and executes it. Usually used for select.
+// Query executes a cached prepared statement. Usually used for select.
+// A replica that cannot be reached is ejected and the query retried on the primary, unless ctx is done.
 func (c *StorageClient) Query(ctx context.Context, sql string, args ...interface{}) (*sql.Rows, error) {
-	stmt, err := c.db.Prepare(sql)
-	if err != nil {
-		return nil, err
+	if r := c.reader(ctx); r != nil {
+		rows, err := r.stmts.query(ctx, sql, args...)
+		if ctx.Err() != nil || !c.replicas.eject(r, err) {
+			return rows, err
+		}
 	}
-	defer stmt.Close()
-	return stmt.QueryContext(ctx, args...)
+	return c.stmts.query(ctx, sql, args...)
 }
 
-// QueryRow prepares a SQL query and executes it. Usually used for select at least one row.
+// QueryRow executes a cached prepared statement. Usually used for select at least one row.
+// A replica that cannot be reached is ejected and the query retried on the primary, unless ctx is done.
 func (c *StorageClient) QueryRow(ctx context.Context, sql string, args ...interface{}) *sql.Row {
-	return c.db.QueryRowContext(ctx, sql, args)
+	if r := c.reader(ctx); r != nil {
+		row := r.stmts.queryRow(ctx, sql, args...)
+		if ctx.Err() != nil || !c.replicas.eject(r, row.Err()) {
+			return row
+		}
+	}
+	return c.stmts.queryRow(ctx, sql, args...)
+}
+
+// StmtCacheStats returns the prepared statement cache counters of the primary and every replica.
+func (c *StorageClient) StmtCacheStats() StmtCacheStats {
+	stats := c.stmts.snapshot()
+	if c.replicas != nil {
+		for _, r := range c.replicas.list {
+			rs := r.stmts.snapshot()
+			stats.Hits += rs.Hits
+			stats.Misses += rs.Misses
+			stats.Evictions += rs.Evictions
+		}
+	}
+	return stats
+}
+
+// reader returns the replica to read from, or nil to read from the primary.
+func (c *StorageClient) reader(ctx context.Context) *replica {
+	if c.replicas == nil || usePrimary(ctx) {
+		return nil
+	}
+	return c.replicas.pick()
 }
 
 // BeginTx starts a transaction for the given context
 func (c *StorageClient) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
 	return c.db.BeginTx(ctx, opts)
 }
+
+// IsDuplicateEntry reports whether err was raised by a unique or primary key violation.
+func IsDuplicateEntry(err error) bool {
+	var e *mysql.MySQLError
+	if errors.As(err, &e) {
+		return e.Number == duplicateEntryErrNumber
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	oops "go-dao-pattern/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unresponsive accepts connections and hangs up at once, as a server that is still starting would.
+func unresponsive(t *testing.T) (ConnectionOptions, *int32) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	accepted := new(int32)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(accepted, 1)
+			conn.Close()
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	return ConnectionOptions{Host: address.IP.String(), Port: address.Port, User: "root", Schema: "company"}, accepted
+}
+
+func TestInitConnection_Unavailable(t *testing.T) {
+	opts, accepted := unresponsive(t)
+	opts.PingRetries = 2
+	opts.PingBackoff = time.Millisecond
+
+	client, err := InitConnection(context.Background(), opts)
+	assert.Nil(t, client)
+	assert.True(t, oops.Is(oops.E5xxUNAVAILABLE, err))
+	assert.Contains(t, oops.ErrorMessage(err), "[attempts: 3]")
+	assert.GreaterOrEqual(t, atomic.LoadInt32(accepted), int32(3))
+}
+
+func TestInitConnection_ConnectTimeout(t *testing.T) {
+	opts, _ := unresponsive(t)
+	opts.PingRetries = 100
+	opts.PingBackoff = 50 * time.Millisecond
+	opts.ConnectTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	client, err := InitConnection(context.Background(), opts)
+	assert.Nil(t, client)
+	assert.True(t, oops.Is(oops.E5xxUNAVAILABLE, err))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestInitConnection_Cancelled(t *testing.T) {
+	opts, _ := unresponsive(t)
+	opts.PingRetries = 100
+	opts.PingBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	client, err := InitConnection(ctx, opts)
+	assert.Nil(t, client)
+	assert.Contains(t, oops.ErrorMessage(err), context.Canceled.Error())
+}
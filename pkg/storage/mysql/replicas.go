@@ -0,0 +1,182 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	RoundRobin Balance = iota
+	LeastConnections
+
+	defaultReplicaCheckInterval = 5 * time.Second
+)
+
+type (
+	// Balance is how reads are spread across the healthy replicas.
+	Balance int
+
+	// Replica is the address of a read replica. Every other setting is taken from the primary.
+	Replica struct {
+		Host   string
+		Port   int
+		Socket string
+	}
+
+	replica struct {
+		db      *sql.DB
+		stmts   *stmtCache
+		address string
+		healthy int32
+	}
+
+	// replicas routes reads. Replicas that fail with a connection error are ejected and pinged
+	// in the background until they answer again.
+	replicas struct {
+		list    []*replica
+		balance Balance
+		next    uint32
+		done    chan struct{}
+		closer  sync.Once
+	}
+
+	primaryKey struct{}
+)
+
+// WithPrimary marks ctx so that reads made with it go to the primary, to read your own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
+
+// openReplicas connects to every replica. One that cannot be reached starts ejected rather than failing the client.
+func openReplicas(ctx context.Context, opts ConnectionOptions) (*replicas, error) {
+	rs := &replicas{balance: opts.ReplicaBalance, done: make(chan struct{})}
+	for _, r := range opts.Replicas {
+		ro := opts
+		ro.Host, ro.Port, ro.Socket = r.Host, r.Port, r.Socket
+		ro.Replicas = nil
+
+		db, err := open(ro)
+		if err != nil {
+			rs.close()
+			return nil, err
+		}
+
+		rep := &replica{db: db, stmts: newStmtCache(db, opts.StmtCacheSize, ro.address()), address: ro.address()}
+		if db.PingContext(ctx) == nil {
+			rep.healthy = 1
+		}
+		rs.list = append(rs.list, rep)
+	}
+
+	interval := opts.ReplicaCheckInterval
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+	go rs.check(interval)
+
+	return rs, nil
+}
+
+// pick returns a healthy replica, or nil when there is none.
+func (rs *replicas) pick() *replica {
+	healthy := make([]*replica, 0, len(rs.list))
+	for _, r := range rs.list {
+		if r.isHealthy() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if rs.balance == LeastConnections {
+		least := healthy[0]
+		for _, r := range healthy[1:] {
+			if r.db.Stats().InUse < least.db.Stats().InUse {
+				least = r
+			}
+		}
+		return least
+	}
+
+	n := atomic.AddUint32(&rs.next, 1)
+	return healthy[int(n-1)%len(healthy)]
+}
+
+// eject takes the replica out of rotation when err shows it cannot be reached.
+func (rs *replicas) eject(r *replica, err error) bool {
+	if !unreachable(err) {
+		return false
+	}
+
+	atomic.StoreInt32(&r.healthy, 0)
+	return true
+}
+
+func (rs *replicas) check(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, r := range rs.list {
+				if r.isHealthy() {
+					continue
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if r.db.PingContext(ctx) == nil {
+					atomic.StoreInt32(&r.healthy, 1)
+				}
+				cancel()
+			}
+		case <-rs.done:
+			return
+		}
+	}
+}
+
+func (rs *replicas) close() error {
+	var err error
+	rs.closer.Do(func() {
+		close(rs.done)
+		for _, r := range rs.list {
+			r.stmts.close()
+			if e := r.db.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// unreachable reports whether err comes from the connection rather than from the query.
+// A cancelled or expired context says nothing about the server, even though its errors are net errors.
+func unreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var ne net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &ne)
+}
@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The replicas are exercised against embedded servers by the integration module:
+// cd integration && go test ./...
+
+func TestUnreachable(t *testing.T) {
+	assert.False(t, unreachable(nil))
+	assert.False(t, unreachable(context.Canceled))
+	assert.False(t, unreachable(context.DeadlineExceeded))
+	assert.False(t, unreachable(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.False(t, unreachable(sql.ErrNoRows))
+	assert.True(t, unreachable(driver.ErrBadConn))
+	assert.True(t, unreachable(&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}))
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"go-dao-pattern/pkg/metrics"
+	"time"
+)
+
+const (
+	openMetric         = "mysql.pool.open"
+	inUseMetric        = "mysql.pool.in_use"
+	idleMetric         = "mysql.pool.idle"
+	waitCountMetric    = "mysql.pool.wait_count"
+	waitDurationMetric = "mysql.pool.wait_duration"
+
+	primaryRole = "primary"
+	replicaRole = "replica"
+)
+
+// gauge publishes the pool gauges. Tests replace it to capture them.
+var gauge = metrics.Gauge
+
+type (
+	// PoolStats describes the connection pools of the client.
+	PoolStats struct {
+		Primary  sql.DBStats
+		Replicas []ReplicaStats
+	}
+
+	ReplicaStats struct {
+		Address string
+		Healthy bool
+		sql.DBStats
+	}
+)
+
+// Ping checks the primary is reachable, so it can back a readiness probe.
+// An unreachable primary yields an E5xxUNAVAILABLE error.
+func (c *StorageClient) Ping(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return unavailable(c.address, 1, err)
+	}
+	return nil
+}
+
+// Stats returns the statistics of the primary pool and of every replica pool.
+func (c *StorageClient) Stats() PoolStats {
+	stats := PoolStats{Primary: c.db.Stats()}
+	if c.replicas != nil {
+		for _, r := range c.replicas.list {
+			stats.Replicas = append(stats.Replicas, ReplicaStats{
+				Address: r.address,
+				Healthy: r.isHealthy(),
+				DBStats: r.db.Stats(),
+			})
+		}
+	}
+	return stats
+}
+
+// report publishes the pool statistics on every interval until the client is closed.
+func (c *StorageClient) report(name string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.publish(name)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *StorageClient) publish(name string) {
+	stats := c.Stats()
+	publish(stats.Primary, "db:"+name, "role:"+primaryRole, "address:"+c.address)
+	for _, r := range stats.Replicas {
+		publish(r.DBStats, "db:"+name, "role:"+replicaRole, "address:"+r.Address)
+	}
+}
+
+func publish(s sql.DBStats, tags ...string) {
+	gauge(openMetric, float64(s.OpenConnections), tags...)
+	gauge(inUseMetric, float64(s.InUse), tags...)
+	gauge(idleMetric, float64(s.Idle), tags...)
+	gauge(waitCountMetric, float64(s.WaitCount), tags...)
+	gauge(waitDurationMetric, float64(s.WaitDuration.Milliseconds()), tags...)
+}
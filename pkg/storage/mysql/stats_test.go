@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	name  string
+	value float64
+	tags  []string
+}
+
+// offline returns a client with a primary and a replica whose pools are never dialled.
+func offline(t *testing.T) *StorageClient {
+	primary, err := sql.Open("mysql", "root:@tcp(127.0.0.1:3306)/company")
+	require.Nil(t, err)
+	secondary, err := sql.Open("mysql", "root:@tcp(127.0.0.1:3307)/company")
+	require.Nil(t, err)
+
+	return &StorageClient{
+		db:      primary,
+		address: "127.0.0.1:3306",
+		stmts:   newStmtCache(primary, 0, "127.0.0.1:3306"),
+		done:    make(chan struct{}),
+		replicas: &replicas{
+			list: []*replica{{db: secondary, stmts: newStmtCache(secondary, 0, "127.0.0.1:3307"), address: "127.0.0.1:3307", healthy: 1}},
+			done: make(chan struct{}),
+		},
+	}
+}
+
+func TestStorageClient_StatsReporter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []published
+	)
+	original := gauge
+	gauge = func(name string, value float64, tags ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, published{name: name, value: value, tags: tags})
+	}
+	t.Cleanup(func() { gauge = original })
+
+	c := offline(t)
+	go c.report("users", 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls) >= 10
+	}, time.Second, 5*time.Millisecond)
+	require.Nil(t, c.Close())
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	names := make(map[string]bool)
+	roles := make(map[string]bool)
+	for _, call := range calls {
+		names[call.name] = true
+		assert.Contains(t, call.tags, "db:users")
+		roles[call.tags[1]] = true
+	}
+	reported := len(calls)
+	mu.Unlock()
+
+	assert.Equal(t, map[string]bool{openMetric: true, inUseMetric: true, idleMetric: true, waitCountMetric: true, waitDurationMetric: true}, names)
+	assert.Equal(t, map[string]bool{"role:primary": true, "role:replica": true}, roles)
+
+	// Closing the client stops the reporter.
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, reported, len(calls))
+	mu.Unlock()
+}
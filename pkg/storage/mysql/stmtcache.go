@@ -0,0 +1,199 @@
+package mysql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"go-dao-pattern/pkg/metrics"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultStmtCacheSize = 128
+
+	stmtHitsMetric      = "mysql.stmt_cache.hits"
+	stmtMissesMetric    = "mysql.stmt_cache.misses"
+	stmtEvictionsMetric = "mysql.stmt_cache.evictions"
+)
+
+type (
+	// stmtCache keeps the most recently used prepared statements of a pool, keyed by SQL text.
+	// database/sql prepares them again on every connection that needs them.
+	stmtCache struct {
+		mu       sync.Mutex
+		db       *sql.DB
+		capacity int
+		lru      *list.List
+		byQuery  map[string]*list.Element
+		tags     []string
+		stats    StmtCacheStats
+	}
+
+	// StmtCacheStats counts prepared statement cache events since the client was created.
+	StmtCacheStats struct {
+		Hits      uint64
+		Misses    uint64
+		Evictions uint64
+	}
+
+	// cachedStmt counts the calls using the statement, so eviction never closes it under them.
+	cachedStmt struct {
+		query   string
+		stmt    *sql.Stmt
+		refs    int
+		evicted bool
+	}
+)
+
+// newStmtCache returns a cache of size statements. Zero uses the default size, and a negative
+// size disables caching, leaving database/sql to prepare the statements it needs on every call.
+func newStmtCache(db *sql.DB, size int, address string) *stmtCache {
+	switch {
+	case size == 0:
+		size = defaultStmtCacheSize
+	case size < 0:
+		size = 0
+	}
+
+	return &stmtCache{
+		db:       db,
+		capacity: size,
+		lru:      list.New(),
+		byQuery:  make(map[string]*list.Element),
+		tags:     []string{"address:" + address},
+	}
+}
+
+func (c *stmtCache) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if c.capacity == 0 {
+		return c.db.ExecContext(ctx, query, args...)
+	}
+
+	cs, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	return cs.stmt.ExecContext(ctx, args...)
+}
+
+// query runs the statement. The rows stay valid after release: database/sql defers closing a
+// statement until the rows it produced are closed.
+func (c *stmtCache) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if c.capacity == 0 {
+		return c.db.QueryContext(ctx, query, args...)
+	}
+
+	cs, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	return cs.stmt.QueryContext(ctx, args...)
+}
+
+// queryRow runs the statement. A statement that fails to prepare is run unprepared,
+// since a sql.Row cannot carry the error otherwise.
+func (c *stmtCache) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if c.capacity == 0 {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+
+	cs, err := c.acquire(ctx, query)
+	if err != nil {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(cs)
+	return cs.stmt.QueryRowContext(ctx, args...)
+}
+
+func (c *stmtCache) acquire(ctx context.Context, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if e, found := c.byQuery[query]; found {
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+
+		c.count(&c.stats.Hits, stmtHitsMetric)
+		return cs, nil
+	}
+	c.mu.Unlock()
+
+	// Preparing takes a round-trip, so it happens outside the lock.
+	c.count(&c.stats.Misses, stmtMissesMetric)
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another call may have prepared the same query meanwhile.
+	if e, found := c.byQuery[query]; found {
+		stmt.Close()
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		c.lru.MoveToFront(e)
+		return cs, nil
+	}
+
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.byQuery[query] = c.lru.PushFront(cs)
+
+	for c.lru.Len() > c.capacity {
+		c.evict(c.lru.Back())
+	}
+	return cs, nil
+}
+
+func (c *stmtCache) release(cs *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cs.refs--
+	if cs.evicted && cs.refs == 0 {
+		cs.stmt.Close()
+	}
+}
+
+// evict drops the entry, closing the statement once no call uses it. Callers hold the lock.
+func (c *stmtCache) evict(e *list.Element) {
+	cs := c.lru.Remove(e).(*cachedStmt)
+	delete(c.byQuery, cs.query)
+	cs.evicted = true
+	if cs.refs == 0 {
+		cs.stmt.Close()
+	}
+	c.count(&c.stats.Evictions, stmtEvictionsMetric)
+}
+
+// close drops every statement. Statements in use are closed when their calls finish.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.lru.Len() > 0 {
+		cs := c.lru.Remove(c.lru.Back()).(*cachedStmt)
+		delete(c.byQuery, cs.query)
+		cs.evicted = true
+		if cs.refs == 0 {
+			cs.stmt.Close()
+		}
+	}
+}
+
+func (c *stmtCache) snapshot() StmtCacheStats {
+	return StmtCacheStats{
+		Hits:      atomic.LoadUint64(&c.stats.Hits),
+		Misses:    atomic.LoadUint64(&c.stats.Misses),
+		Evictions: atomic.LoadUint64(&c.stats.Evictions),
+	}
+}
+
+func (c *stmtCache) count(counter *uint64, metric string) {
+	atomic.AddUint64(counter, 1)
+	metrics.IncrementCounter(metric, 1, c.tags...)
+}